@@ -24,6 +24,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // billing time zones have to be available in minimal images
 
 	"github.com/SENERGY-Platform/cost-calculator/pkg"
 	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
@@ -0,0 +1,139 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/controller"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+func init() {
+	endpoints = append(endpoints, BudgetsEndpoint)
+}
+
+func BudgetsEndpoint(router *httprouter.Router, config configuration.Config, ctrl *controller.Controller) {
+	writeResult := func(writer http.ResponseWriter, result any, err error) {
+		if errors.Is(err, controller.ErrNotFound) {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(writer).Encode(result)
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+		}
+	}
+
+	readBudget := func(writer http.ResponseWriter, request *http.Request) (budget model.Budget, ok bool) {
+		err := json.NewDecoder(request.Body).Decode(&budget)
+		if err == nil {
+			err = budget.Validate()
+		}
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return budget, false
+		}
+		return budget, true
+	}
+
+	router.GET("/budgets", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		userId, _, err := getUserId(config, request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		budgets, err := ctrl.GetBudgets(userId)
+		writeResult(writer, budgets, err)
+	})
+
+	router.POST("/budgets", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		userId, _, err := getUserId(config, request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		budget, ok := readBudget(writer, request)
+		if !ok {
+			return
+		}
+		budget, err = ctrl.CreateBudget(userId, budget)
+		writeResult(writer, budget, err)
+	})
+
+	router.GET("/budgets/:id", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		userId, _, err := getUserId(config, request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		budget, err := ctrl.GetBudget(userId, params.ByName("id"))
+		writeResult(writer, budget, err)
+	})
+
+	router.PUT("/budgets/:id", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		userId, _, err := getUserId(config, request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		budget, ok := readBudget(writer, request)
+		if !ok {
+			return
+		}
+		budget, err = ctrl.UpdateBudget(userId, params.ByName("id"), budget)
+		writeResult(writer, budget, err)
+	})
+
+	router.DELETE("/budgets/:id", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		userId, _, err := getUserId(config, request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = ctrl.DeleteBudget(userId, params.ByName("id"))
+		if errors.Is(err, controller.ErrNotFound) {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	})
+
+	router.GET("/budgets/:id/alerts", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		userId, _, err := getUserId(config, request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		alerts, err := ctrl.GetBudgetAlerts(userId, params.ByName("id"))
+		writeResult(writer, alerts, err)
+	})
+}
@@ -0,0 +1,69 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/controller"
+	"github.com/julienschmidt/httprouter"
+)
+
+func init() {
+	endpoints = append(endpoints, CompareEndpoint)
+}
+
+func CompareEndpoint(router *httprouter.Router, config configuration.Config, controller *controller.Controller) {
+	router.GET("/compare", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		userId, admin, err := getUserId(config, request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		token := getToken(request)
+		times := map[string]time.Time{}
+		for _, name := range []string{"a_start", "a_end", "b_start", "b_end"} {
+			times[name], err = parseRequiredTime(request.URL.Query(), name)
+			if err != nil {
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		deltas, err := controller.CompareCostTrees(userId, token, admin, times["a_start"], times["a_end"], times["b_start"], times["b_end"], request.URL.Query().Get("currency"))
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(writer).Encode(deltas)
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+		}
+	})
+}
+
+func parseRequiredTime(values url.Values, name string) (time.Time, error) {
+	if values.Get(name) == "" {
+		return time.Time{}, fmt.Errorf("missing query parameter %v", name)
+	}
+	return time.Parse(time.RFC3339, values.Get(name))
+}
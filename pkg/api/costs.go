@@ -18,6 +18,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -26,6 +27,7 @@ import (
 
 	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
 	"github.com/SENERGY-Platform/cost-calculator/pkg/controller"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -49,17 +51,40 @@ func CostsEndpoint(router *httprouter.Router, config configuration.Config, contr
 				return
 			}
 		}
-		start, end, err := parseStartEnd(request.URL.Query())
+		start, end, err := parseStartEnd(request.URL.Query(), controller.GetBillingCalendar())
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		estimation, err := parseEstimationOptions(request.URL.Query())
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		format, err := getFormat(request)
 		if err != nil {
 			http.Error(writer, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		overview, err := controller.GetCostControllers(userId, token, admin, params.ByName("costType"), skipEstimation, start, end)
+		overview, err := controller.GetCostControllers(userId, token, admin, params.ByName("costType"), skipEstimation, estimation, start, end)
 		if err != nil {
 			http.Error(writer, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		overview, err = controller.ConvertCostWithChildren(overview, request.URL.Query().Get("currency"))
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if format != formatJson {
+			err = writeCostRows(writer, format, overview.Flatten(params.ByName("costType")))
+			if err != nil {
+				fmt.Println("ERROR: " + err.Error())
+			}
+			return
+		}
 		writer.Header().Set("Content-Type", "application/json")
 		err = json.NewEncoder(writer).Encode(overview)
 		if err != nil {
@@ -82,16 +107,38 @@ func CostsEndpoint(router *httprouter.Router, config configuration.Config, contr
 				return
 			}
 		}
-		start, end, err := parseStartEnd(request.URL.Query())
+		start, end, err := parseStartEnd(request.URL.Query(), controller.GetBillingCalendar())
 		if err != nil {
 			http.Error(writer, err.Error(), http.StatusBadRequest)
 			return
 		}
-		overview, err := controller.GetCostTree(userId, token, admin, skipEstimation, start, end)
+		estimation, err := parseEstimationOptions(request.URL.Query())
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		format, err := getFormat(request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		overview, err := controller.GetCostTree(userId, token, admin, skipEstimation, estimation, start, end)
 		if err != nil {
 			http.Error(writer, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		overview, err = controller.ConvertCostTree(overview, request.URL.Query().Get("currency"))
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if format != formatJson {
+			err = writeCostRows(writer, format, overview.Flatten())
+			if err != nil {
+				fmt.Println("ERROR: " + err.Error())
+			}
+			return
+		}
 		writer.Header().Set("Content-Type", "application/json")
 		err = json.NewEncoder(writer).Encode(overview)
 		if err != nil {
@@ -104,20 +151,50 @@ func CostsEndpoint(router *httprouter.Router, config configuration.Config, contr
 	})
 }
 
-func parseStartEnd(values url.Values) (start, end *time.Time, err error) {
+// parseStartEnd resolves the named period or start and end, which may be RFC3339 timestamps or dates.
+// An end date includes the whole day, but not the future.
+func parseStartEnd(values url.Values, calendar model.BillingCalendar) (start, end *time.Time, err error) {
+	if len(values.Get("period")) > 0 {
+		if len(values.Get("start")) > 0 || len(values.Get("end")) > 0 {
+			return start, end, errors.New("must not provide period with start or end")
+		}
+		s, e, err := calendar.Preset(values.Get("period"), time.Now())
+		if err != nil {
+			return start, end, err
+		}
+		return &s, &e, nil
+	}
 	if len(values.Get("start")) > 0 {
-		s, err := time.Parse(time.RFC3339, values.Get("start"))
+		s, err := calendar.ParseTime(values.Get("start"))
 		if err != nil {
 			return start, end, err
 		}
 		start = &s
 	}
 	if len(values.Get("end")) > 0 {
-		s, err := time.Parse(time.RFC3339, values.Get("end"))
+		s, err := calendar.ParseTime(values.Get("end"))
 		if err != nil {
 			return start, end, err
 		}
+		if len(values.Get("end")) == len(time.DateOnly) {
+			s = s.AddDate(0, 0, 1)
+			if now := time.Now(); s.After(now) {
+				s = now
+			}
+		}
 		end = &s
 	}
 	return
 }
+
+func parseEstimationOptions(values url.Values) (options model.EstimationOptions, err error) {
+	options.Strategy = values.Get("strategy")
+	if len(values.Get("horizon")) > 0 {
+		horizon, err := time.Parse(time.RFC3339, values.Get("horizon"))
+		if err != nil {
+			return options, err
+		}
+		options.Horizon = &horizon
+	}
+	return options, options.Validate()
+}
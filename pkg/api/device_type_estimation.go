@@ -0,0 +1,66 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/controller"
+	"github.com/julienschmidt/httprouter"
+)
+
+func init() {
+	endpoints = append(endpoints, DeviceTypeEstimationEndpoint)
+}
+
+func DeviceTypeEstimationEndpoint(router *httprouter.Router, config configuration.Config, ctrl *controller.Controller) {
+	router.GET("/estimation/device-type/:id", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		_, _, err := getUserId(config, request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		token := getToken(request)
+		var count int64 = 1
+		if len(request.URL.Query().Get("count")) > 0 {
+			count, err = strconv.ParseInt(request.URL.Query().Get("count"), 10, 64)
+			if err != nil || count < 1 {
+				http.Error(writer, "count must be a positive integer", http.StatusBadRequest)
+				return
+			}
+		}
+		estimation, err := ctrl.GetDeviceTypeEstimation(token, params.ByName("id"), count)
+		if errors.Is(err, controller.ErrNotFound) {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(writer).Encode(estimation)
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+		}
+	})
+}
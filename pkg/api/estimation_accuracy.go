@@ -0,0 +1,56 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/controller"
+	"github.com/SENERGY-Platform/service-commons/pkg/jwt"
+	"github.com/julienschmidt/httprouter"
+)
+
+func init() {
+	endpoints = append(endpoints, EstimationAccuracyEndpoint)
+}
+
+func EstimationAccuracyEndpoint(router *httprouter.Router, config configuration.Config, controller *controller.Controller) {
+	router.GET("/estimation/accuracy", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		token, err := jwt.GetParsedToken(request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !token.IsAdmin() {
+			http.Error(writer, "forbidden", http.StatusForbidden)
+			return
+		}
+		accuracy, err := controller.GetEstimationAccuracy(request.URL.Query().Get("month"))
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(writer).Encode(accuracy)
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+		}
+	})
+}
@@ -0,0 +1,97 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/xuri/excelize/v2"
+)
+
+const formatJson = "json"
+const formatCsv = "csv"
+const formatXlsx = "xlsx"
+
+func getFormat(request *http.Request) (string, error) {
+	switch format := request.URL.Query().Get("format"); format {
+	case "", formatJson:
+		return formatJson, nil
+	case formatCsv, formatXlsx:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown format %v", format)
+	}
+}
+
+// writeCostRows writes the rows as csv or xlsx file
+func writeCostRows(writer http.ResponseWriter, format string, rows []model.CostRow) (err error) {
+	switch format {
+	case formatCsv:
+		writer.Header().Set("Content-Type", "text/csv")
+		writer.Header().Set("Content-Disposition", "attachment; filename=\"costs.csv\"")
+		w := csv.NewWriter(writer)
+		err = w.Write(model.CostRowColumns)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			record := []string{}
+			for _, cell := range row.Cells() {
+				switch v := cell.(type) {
+				case float64:
+					record = append(record, strconv.FormatFloat(v, 'f', -1, 64))
+				default:
+					record = append(record, fmt.Sprint(v))
+				}
+			}
+			err = w.Write(record)
+			if err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	case formatXlsx:
+		f := excelize.NewFile()
+		defer f.Close()
+		sheet := f.GetSheetName(0)
+		header := []any{}
+		for _, column := range model.CostRowColumns {
+			header = append(header, column)
+		}
+		err = f.SetSheetRow(sheet, "A1", &header)
+		if err != nil {
+			return err
+		}
+		for i, row := range rows {
+			cells := row.Cells()
+			err = f.SetSheetRow(sheet, "A"+strconv.Itoa(i+2), &cells)
+			if err != nil {
+				return err
+			}
+		}
+		writer.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		writer.Header().Set("Content-Disposition", "attachment; filename=\"costs.xlsx\"")
+		return f.Write(writer)
+	default:
+		return fmt.Errorf("unknown format %v", format)
+	}
+}
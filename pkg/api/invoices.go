@@ -0,0 +1,70 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/controller"
+	"github.com/julienschmidt/httprouter"
+)
+
+func init() {
+	endpoints = append(endpoints, InvoicesEndpoint)
+}
+
+func InvoicesEndpoint(router *httprouter.Router, config configuration.Config, ctrl *controller.Controller) {
+	router.GET("/invoices/:month", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		userId, admin, err := getUserId(config, request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		token := getToken(request)
+		invoice, err := ctrl.GetInvoice(userId, token, admin, params.ByName("month"))
+		if errors.Is(err, controller.ErrMonthNotClosed) {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, controller.ErrNotFound) {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if request.URL.Query().Get("format") == "pdf" || request.Header.Get("Accept") == "application/pdf" {
+			writer.Header().Set("Content-Type", "application/pdf")
+			writer.Header().Set("Content-Disposition", "attachment; filename=\"invoice-"+invoice.Number+".pdf\"")
+			err = controller.WriteInvoicePdf(invoice, writer)
+			if err != nil {
+				fmt.Println("ERROR: " + err.Error())
+			}
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(writer).Encode(invoice)
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+		}
+	})
+}
@@ -0,0 +1,100 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/controller"
+	"github.com/julienschmidt/httprouter"
+)
+
+func init() {
+	endpoints = append(endpoints, ProcessEstimationEndpoint)
+}
+
+func ProcessEstimationEndpoint(router *httprouter.Router, config configuration.Config, ctrl *controller.Controller) {
+	router.GET("/estimation/process", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		userId, _, err := getUserId(config, request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		processDefinitionId := request.URL.Query().Get("definition")
+		var tasks int64
+		if len(request.URL.Query().Get("tasks")) > 0 {
+			tasks, err = strconv.ParseInt(request.URL.Query().Get("tasks"), 10, 64)
+			if err != nil || tasks < 1 {
+				http.Error(writer, "tasks must be a positive integer", http.StatusBadRequest)
+				return
+			}
+		}
+		if processDefinitionId != "" && tasks > 0 {
+			http.Error(writer, "must not provide definition and tasks", http.StatusBadRequest)
+			return
+		}
+		estimation, err := ctrl.GetProcessEstimation(userId, processDefinitionId, tasks)
+		if errors.Is(err, controller.ErrNotConfigured) {
+			http.Error(writer, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if errors.Is(err, controller.ErrNotFound) {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(writer).Encode(estimation)
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+		}
+	})
+
+	router.POST("/estimation/process", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		userId, _, err := getUserId(config, request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		estimation, err := ctrl.GetBpmnProcessEstimation(userId, request.Body)
+		if errors.Is(err, controller.ErrNotConfigured) {
+			http.Error(writer, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if errors.Is(err, controller.ErrInvalidBpmn) {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(writer).Encode(estimation)
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+		}
+	})
+}
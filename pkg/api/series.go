@@ -0,0 +1,80 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/controller"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/julienschmidt/httprouter"
+)
+
+func init() {
+	endpoints = append(endpoints, SeriesEndpoint)
+}
+
+func SeriesEndpoint(router *httprouter.Router, config configuration.Config, ctrl *controller.Controller) {
+	router.GET("/series/:costType", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		userId, admin, err := getUserId(config, request)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		start, end, err := parseStartEnd(request.URL.Query(), ctrl.GetBillingCalendar())
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		step := 24 * time.Hour
+		if len(request.URL.Query().Get("step")) > 0 {
+			step, err = time.ParseDuration(request.URL.Query().Get("step"))
+			if err != nil {
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		children := false
+		if len(request.URL.Query().Get("children")) > 0 {
+			children, err = strconv.ParseBool(request.URL.Query().Get("children"))
+			if err != nil {
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		series, err := ctrl.GetSeries(userId, getToken(request), admin, model.CostType(params.ByName("costType")), start, end, step, children)
+		if err != nil {
+			if errors.Is(err, controller.ErrInvalidSeriesRequest) {
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(writer).Encode(series)
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+		}
+	})
+}
@@ -0,0 +1,36 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package client
+
+import (
+	"net/http"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func (c *impl) GetAnomalies(token string, forUser *string) ([]model.CostAnomaly, error) {
+	url := c.baseUrl + "/anomalies"
+	if forUser != nil {
+		url += "?for_user=" + *forUser
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[[]model.CostAnomaly](req)
+}
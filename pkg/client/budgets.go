@@ -0,0 +1,88 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func (c *impl) GetBudgets(token string) ([]model.Budget, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseUrl+"/budgets", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[[]model.Budget](req)
+}
+
+func (c *impl) GetBudget(token string, id string) (model.Budget, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseUrl+"/budgets/"+id, nil)
+	if err != nil {
+		return model.Budget{}, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[model.Budget](req)
+}
+
+func (c *impl) CreateBudget(token string, budget model.Budget) (model.Budget, error) {
+	b, err := json.Marshal(budget)
+	if err != nil {
+		return model.Budget{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseUrl+"/budgets", bytes.NewBuffer(b))
+	if err != nil {
+		return model.Budget{}, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[model.Budget](req)
+}
+
+func (c *impl) UpdateBudget(token string, budget model.Budget) (model.Budget, error) {
+	b, err := json.Marshal(budget)
+	if err != nil {
+		return model.Budget{}, err
+	}
+	req, err := http.NewRequest(http.MethodPut, c.baseUrl+"/budgets/"+budget.Id, bytes.NewBuffer(b))
+	if err != nil {
+		return model.Budget{}, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[model.Budget](req)
+}
+
+func (c *impl) DeleteBudget(token string, id string) error {
+	req, err := http.NewRequest(http.MethodDelete, c.baseUrl+"/budgets/"+id, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", token)
+	_, err = do[any](req)
+	return err
+}
+
+func (c *impl) GetBudgetAlerts(token string, id string) ([]model.BudgetAlert, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseUrl+"/budgets/"+id+"/alerts", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[[]model.BudgetAlert](req)
+}
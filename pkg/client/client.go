@@ -33,6 +33,24 @@ type Client interface {
 	GetFlowEstimations(token string, flowIds []string) ([]model.Estimation, error)
 	GetImportEstimation(token string, importTypeId string) (model.Estimation, error)
 	GetImportEstimations(token string, importTypeIds []string) ([]model.Estimation, error)
+	GetProcessEstimation(token string, processDefinitionId string, tasks int64) (model.Estimation, error)
+	GetBpmnProcessEstimation(token string, bpmn string) (model.Estimation, error)
+	GetDeviceTypeEstimation(token string, deviceTypeId string, count int64) (model.DeviceTypeEstimation, error)
+	GetPricingModel(token string) (model.PricingModel, error)
+	SetPricingModel(token string, version model.PricingModel) (model.PricingChange, error)
+	GetPricingHistory(token string) ([]model.PricingChange, error)
+	GetSeries(token string, costType model.CostType, start *time.Time, end *time.Time, step time.Duration, children bool, forUser *string) (model.CostSeries, error)
+	GetInvoice(token string, month string, forUser *string) (model.Invoice, error)
+	GetInvoicePdf(token string, month string, forUser *string) ([]byte, error)
+	GetBudgets(token string) ([]model.Budget, error)
+	GetBudget(token string, id string) (model.Budget, error)
+	CreateBudget(token string, budget model.Budget) (model.Budget, error)
+	UpdateBudget(token string, budget model.Budget) (model.Budget, error)
+	DeleteBudget(token string, id string) error
+	GetBudgetAlerts(token string, id string) ([]model.BudgetAlert, error)
+	GetAnomalies(token string, forUser *string) ([]model.CostAnomaly, error)
+	Compare(token string, aStart time.Time, aEnd time.Time, bStart time.Time, bEnd time.Time, forUser *string) ([]model.CostDelta, error)
+	GetEstimationAccuracy(token string, month string) ([]model.EstimationAccuracy, error)
 }
 
 type impl struct {
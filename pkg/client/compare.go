@@ -0,0 +1,42 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func (c *impl) Compare(token string, aStart time.Time, aEnd time.Time, bStart time.Time, bEnd time.Time, forUser *string) ([]model.CostDelta, error) {
+	query := url.Values{}
+	query.Set("a_start", aStart.Format(time.RFC3339))
+	query.Set("a_end", aEnd.Format(time.RFC3339))
+	query.Set("b_start", bStart.Format(time.RFC3339))
+	query.Set("b_end", bEnd.Format(time.RFC3339))
+	if forUser != nil {
+		query.Set("for_user", *forUser)
+	}
+	req, err := http.NewRequest(http.MethodGet, c.baseUrl+"/compare?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[[]model.CostDelta](req)
+}
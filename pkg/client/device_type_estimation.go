@@ -0,0 +1,34 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func (c *impl) GetDeviceTypeEstimation(token string, deviceTypeId string, count int64) (model.DeviceTypeEstimation, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseUrl+"/estimation/device-type/"+url.PathEscape(deviceTypeId)+"?count="+strconv.FormatInt(count, 10), nil)
+	if err != nil {
+		return model.DeviceTypeEstimation{}, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[model.DeviceTypeEstimation](req)
+}
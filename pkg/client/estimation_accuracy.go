@@ -0,0 +1,38 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+// GetEstimationAccuracy requires an admin token. All months are evaluated, if month is empty.
+func (c *impl) GetEstimationAccuracy(token string, month string) ([]model.EstimationAccuracy, error) {
+	u := c.baseUrl + "/estimation/accuracy"
+	if month != "" {
+		u += "?month=" + url.QueryEscape(month)
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[[]model.EstimationAccuracy](req)
+}
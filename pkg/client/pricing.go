@@ -0,0 +1,56 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func (c *impl) GetPricingModel(token string) (model.PricingModel, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseUrl+"/pricing", nil)
+	if err != nil {
+		return model.PricingModel{}, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[model.PricingModel](req)
+}
+
+func (c *impl) SetPricingModel(token string, version model.PricingModel) (model.PricingChange, error) {
+	b, err := json.Marshal(version)
+	if err != nil {
+		return model.PricingChange{}, err
+	}
+	req, err := http.NewRequest(http.MethodPut, c.baseUrl+"/pricing", bytes.NewBuffer(b))
+	if err != nil {
+		return model.PricingChange{}, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[model.PricingChange](req)
+}
+
+func (c *impl) GetPricingHistory(token string) ([]model.PricingChange, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseUrl+"/pricing/history", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[[]model.PricingChange](req)
+}
@@ -0,0 +1,55 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+// GetProcessEstimation estimates the monthly cost of a process definition of the user or, if processDefinitionId is empty,
+// of a process definition with the number of tasks. Both may be empty to estimate an average process definition.
+func (c *impl) GetProcessEstimation(token string, processDefinitionId string, tasks int64) (model.Estimation, error) {
+	query := url.Values{}
+	if processDefinitionId != "" {
+		query.Set("definition", processDefinitionId)
+	}
+	if tasks > 0 {
+		query.Set("tasks", strconv.FormatInt(tasks, 10))
+	}
+	req, err := http.NewRequest(http.MethodGet, c.baseUrl+"/estimation/process?"+query.Encode(), nil)
+	if err != nil {
+		return model.Estimation{}, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[model.Estimation](req)
+}
+
+// GetBpmnProcessEstimation estimates the monthly cost of the process model in BPMN XML by the number of its tasks
+func (c *impl) GetBpmnProcessEstimation(token string, bpmn string) (model.Estimation, error) {
+	req, err := http.NewRequest(http.MethodPost, c.baseUrl+"/estimation/process", strings.NewReader(bpmn))
+	if err != nil {
+		return model.Estimation{}, err
+	}
+	req.Header.Set("Authorization", token)
+	req.Header.Set("Content-Type", "application/xml")
+	return do[model.Estimation](req)
+}
@@ -0,0 +1,44 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func (c *impl) GetSeries(token string, costType model.CostType, start *time.Time, end *time.Time, step time.Duration, children bool, forUser *string) (model.CostSeries, error) {
+	url := c.baseUrl + "/series/" + costType + "?step=" + step.String() + "&children=" + strconv.FormatBool(children)
+	if start != nil {
+		url += "&start=" + start.Format(time.RFC3339)
+	}
+	if end != nil {
+		url += "&end=" + end.Format(time.RFC3339)
+	}
+	if forUser != nil {
+		url += "&for_user=" + *forUser
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return model.CostSeries{}, err
+	}
+	req.Header.Set("Authorization", token)
+	return do[model.CostSeries](req)
+}
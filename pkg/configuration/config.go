@@ -43,6 +43,13 @@ type ConfigStruct struct {
 	UserProcessDefinitionCostFractionQuery string `json:"user_process_definition_cost_fraction_query"`
 	UserProcessIoCostFractionQuery         string `json:"user_process_io_cost_fraction_query"`
 	CustomPrometheusLabels                 string `json:"custom_prometheus_labels"`
+	DeviceMessageTypeLabel                 string `json:"device_message_type_label"` // label of the device message series, which is used as message type for device_messages_by_type prices
+
+	// queries of the process estimation, which return the fractions of all users at once. Without them,
+	// process estimations respond with 501
+	ProcessCostFractionByUserQuery           string `json:"process_cost_fraction_by_user_query"`            // like user_process_cost_fraction_query, returns a vector by user_id
+	ProcessDefinitionCostFractionByUserQuery string `json:"process_definition_cost_fraction_by_user_query"` // like user_process_definition_cost_fraction_query, returns a vector by user_id and process_definition_id
+	ProcessDefinitionTaskCountQuery          string `json:"process_definition_task_count_query"`            // optional, returns a vector with the number of tasks per process definition id. Without it, process estimations by tasks or bpmn respond with 501
 
 	ProcessCostSources     map[string][]string `json:"process_cost_sources"`
 	MarshallingCostSources map[string][]string `json:"marshalling_cost_sources"`
@@ -51,10 +58,50 @@ type ConfigStruct struct {
 	PermissionsUrl                string `json:"permissions_url"`
 	PermissionsV2Url              string `json:"permissions_v2_url"`
 	PricingModelFilePath          string `json:"pricing_model_file_path"`
+	PricingModelReloadInterval    string `json:"pricing_model_reload_interval"`
+	DatabaseFilePath              string `json:"database_file_path"`
 	UserManagementUrl             string `json:"user_management_url"`
 	ServingUrl                    string `json:"serving_url"`
 	ServingTimescaleConfiguredUrl string `json:"serving_timescale_configured_url"`
 
+	ExchangeRatesFilePath string `json:"exchange_rates_file_path"`
+	RoundingMode          string `json:"rounding_mode"`
+	RoundingDecimals      int64  `json:"rounding_decimals"`
+
+	BillingModes map[string]string `json:"billing_modes"` // billing mode by cost type, see model.BillingMode
+
+	EstimationStrategies map[string]string `json:"estimation_strategies"` // estimation strategy by cost type, see model.EstimationStrategy
+
+	OverheadEnabled    bool     `json:"overhead_enabled"`
+	OverheadNamespaces []string `json:"overhead_namespaces"` // usage of these namespaces is distributed to all users
+
+	BillingTimeZone  string `json:"billing_time_zone"`  // IANA time zone of the billing periods, defaults to UTC
+	BillingAnchorDay int64  `json:"billing_anchor_day"` // day of the month, on which billing periods start, 1 to 28
+
+	SnapshotsEnabled     bool     `json:"snapshots_enabled"`
+	SnapshotInterval     string   `json:"snapshot_interval"`
+	SnapshotUsersQueries []string `json:"snapshot_users_queries"` // each returns a vector with one element per user id, may use $__range
+
+	AuthEndpoint     string `json:"auth_endpoint"`
+	AuthClientId     string `json:"auth_client_id"`
+	AuthClientSecret string `json:"auth_client_secret"`
+
+	InvoiceTaxes map[string]string `json:"invoice_taxes"` // tax rate by name, e.g. "VAT": "0.19"
+
+	BudgetsEnabled   bool   `json:"budgets_enabled"`
+	BudgetInterval   string `json:"budget_interval"`
+	BudgetThresholds string `json:"budget_thresholds"` // comma separated percentages of the budget, e.g. "50,80,100"
+	BudgetWebhookUrl string `json:"budget_webhook_url"`
+
+	AnomaliesEnabled   bool    `json:"anomalies_enabled"`
+	AnomalyInterval    string  `json:"anomaly_interval"`
+	AnomalyHistoryDays int64   `json:"anomaly_history_days"` // number of days before the checked day, which are used as history
+	AnomalyThreshold   float64 `json:"anomaly_threshold"`    // minimal number of standard deviations above the mean of the history
+	AnomalyWebhookUrl  string  `json:"anomaly_webhook_url"`  // optional
+
+	EstimationRecordsEnabled bool   `json:"estimation_records_enabled"`
+	EstimationRecordInterval string `json:"estimation_record_interval"` // estimations are recorded once per interval to evaluate their accuracy
+
 	ProcessCostSourceToInstanceIdPlaceholderForProcessDefCostFraction map[string]string `json:"process_cost_source_to_instance_id_placeholder_for_process_def_cost_fraction"`
 }
 
@@ -17,24 +17,32 @@
 package controller
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
 )
 
-var d24h = time.Hour * 24
-
-func (c *Controller) GetAnalyticsTree(userId string, skipEstimation bool, start *time.Time, end *time.Time) (tree model.CostWithChildren, err error) {
+func (c *Controller) GetAnalyticsTree(userId string, skipEstimation bool, estimation model.EstimationOptions, start *time.Time, end *time.Time) (tree model.CostWithChildren, err error) {
 	timer := time.Now()
 
-	if (start == nil && end != nil) || (start != nil && end == nil) || (start != nil && !skipEstimation) {
-		return tree, fmt.Errorf("must not provide only one of start or end. must not provide start and stop without skipEstimation")
+	start, end, err = c.checkStartEnd(skipEstimation, start, end)
+	if err != nil {
+		return tree, err
+	}
+	var proj *projection
+	if !skipEstimation {
+		proj, err = c.newProjection(model.CostTypeAnalytics, estimation, *end)
+		if err != nil {
+			return tree, err
+		}
 	}
 	filter := &statsFilter{
-		CPU:     true,
-		RAM:     true,
-		Storage: true,
+		CPU:        true,
+		RAM:        true,
+		Storage:    true,
+		Network:    true,
+		CostType:   model.CostTypeAnalytics,
+		Estimation: proj,
 		filter: filter{
 			Namespace: &c.config.NamespaceAnalytics,
 			Labels: map[string][]string{
@@ -44,9 +52,6 @@ func (c *Controller) GetAnalyticsTree(userId string, skipEstimation bool, start
 			End:   end,
 		},
 	}
-	if !skipEstimation {
-		filter.PredictionBasedOn = &d24h
-	}
 	stats, err := c.getStats(filter)
 	if err != nil {
 		return
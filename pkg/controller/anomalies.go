@@ -0,0 +1,130 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func (c *Controller) GetAnomalies(userId string) ([]model.CostAnomaly, error) {
+	return c.db.ListAnomalies(userId)
+}
+
+// runAnomalyDetection checks the last day immediately and then every interval, if it has to be checked
+func (c *Controller) runAnomalyDetection(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := c.detectAnomalies()
+		if err != nil {
+			log.Println("ERROR: unable to detect anomalies, retry in", interval, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// detectAnomalies compares the cost of the last day of all users with the daily costs of the configured number of
+// days before and stores the anomalies. Days are calendar days in the time zone of the billing calendar.
+// Daily cost trees are stored, so that each day is only calculated once. Users, which fail, are logged and
+// checked again on the next run.
+func (c *Controller) detectAnomalies() error {
+	end := c.billing.DayStart(time.Now())
+	start := end.AddDate(0, 0, -1)
+	day := start.Format(model.AnomalyDayFormat)
+	if c.tokenProvider == nil {
+		return fmt.Errorf("missing auth_endpoint config")
+	}
+	token, err := c.tokenProvider()
+	if err != nil {
+		return err
+	}
+	users, err := c.getUsers(token, start, end)
+	if err != nil {
+		return err
+	}
+	for userId := range users {
+		checked, err := c.db.AnomaliesChecked(userId, day)
+		if err != nil {
+			return err
+		}
+		if checked {
+			continue
+		}
+		current, history, err := c.getDailyCostWithHistory(userId, token, start)
+		if err != nil {
+			log.Println("ERROR: unable to calculate daily cost of", userId, err)
+			continue
+		}
+		anomalies := model.DetectAnomalies(current, history, c.config.AnomalyThreshold)
+		for i := range anomalies {
+			anomalies[i].UserId = userId
+			anomalies[i].Day = day
+			if c.config.AnomalyWebhookUrl != "" {
+				err = postWebhook(c.config.AnomalyWebhookUrl, anomalies[i])
+				if err != nil {
+					log.Println("ERROR: unable to send anomaly", userId, anomalies[i].Path, err)
+				}
+			}
+		}
+		err = c.db.SetAnomalies(userId, day, anomalies)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getDailyCostWithHistory returns the daily cost of the user for the day starting at start and for the configured
+// number of days before
+func (c *Controller) getDailyCostWithHistory(userId string, token string, start time.Time) (current model.CostTree, history []model.CostTree, err error) {
+	current, err = c.getDailyCost(userId, token, start)
+	if err != nil {
+		return current, history, err
+	}
+	for i := c.config.AnomalyHistoryDays; i > 0; i-- {
+		tree, err := c.getDailyCost(userId, token, start.AddDate(0, 0, -int(i)))
+		if err != nil {
+			return current, history, err
+		}
+		history = append(history, tree)
+	}
+	return current, history, nil
+}
+
+// getDailyCost returns the cost tree of the user for the day starting at start
+func (c *Controller) getDailyCost(userId string, token string, start time.Time) (model.CostTree, error) {
+	day := start.Format(model.AnomalyDayFormat)
+	tree, found, err := c.db.GetDailyCost(day, userId)
+	if err != nil || found {
+		return tree, err
+	}
+	end := start.AddDate(0, 0, 1)
+	tree, err = c.getCostTreeWithTiers(userId, token, true, true, model.EstimationOptions{}, &start, &end)
+	if err != nil {
+		return tree, err
+	}
+	return tree, c.db.SetDailyCost(day, userId, tree)
+}
@@ -0,0 +1,62 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/database"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func TestGetDailyCostWithHistory(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	billing, err := model.NewBillingCalendar("Europe/Berlin", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Controller{db: db, billing: billing, config: &configuration.ConfigStruct{AnomalyHistoryDays: 2}}
+
+	// days are calendar days of the billing time zone
+	start := c.billing.DayStart(time.Date(2024, 3, 14, 23, 30, 0, 0, time.UTC)).AddDate(0, 0, -1)
+	if day := start.Format(model.AnomalyDayFormat); day != "2024-03-14" {
+		t.Fatalf("unexpected day %v", day)
+	}
+	for i, day := range []string{"2024-03-12", "2024-03-13", "2024-03-14"} {
+		err = db.SetDailyCost(day, "user", model.CostTree{model.CostTypeAnalytics: {CostWithEstimation: model.CostWithEstimation{Month: model.CostEntry{Cpu: model.ToDecimal(float64(i + 1))}}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	current, history, err := c.getDailyCostWithHistory("user", "", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost := current[model.CostTypeAnalytics].Month.Cpu.InexactFloat64(); cost != 3 {
+		t.Fatalf("unexpected current cost %v", cost)
+	}
+	if len(history) != 2 || history[0][model.CostTypeAnalytics].Month.Cpu.InexactFloat64() != 1 || history[1][model.CostTypeAnalytics].Month.Cpu.InexactFloat64() != 2 {
+		t.Fatalf("unexpected history %#v", history)
+	}
+}
@@ -28,14 +28,19 @@ import (
 	prometheus_model "github.com/prometheus/common/model"
 )
 
-func (c *Controller) GetApiCallsTree(username string, skipEstimation bool, start *time.Time, end *time.Time) (result model.CostWithChildren, err error) {
+func (c *Controller) GetApiCallsTree(username string, skipEstimation bool, estimation model.EstimationOptions, start *time.Time, end *time.Time) (result model.CostWithChildren, err error) {
 	timer := time.Now()
 
-	if (start == nil && end != nil) || (start != nil && end == nil) || (start != nil && !skipEstimation) {
-		return result, fmt.Errorf("must not provide only one of start or end. must not provide start and stop without skipEstimation")
+	start, end, err = c.checkStartEnd(skipEstimation, start, end)
+	if err != nil {
+		return result, err
 	}
-	if start == nil {
-		start, end = defaultStartEnd()
+	var proj *projection
+	if !skipEstimation {
+		proj, err = c.newProjection(model.CostTypeApiCalls, estimation, *end)
+		if err != nil {
+			return result, err
+		}
 	}
 	result = model.CostWithChildren{
 		CostWithEstimation: model.CostWithEstimation{
@@ -44,74 +49,109 @@ func (c *Controller) GetApiCallsTree(username string, skipEstimation bool, start
 		},
 		Children: map[string]model.CostWithChildren{},
 	}
-	nextMonth := time.Date(time.Now().Year(), time.Now().Month()+1, 0, 0, 0, 0, 0, time.UTC) // this is okay, because multiplier is only used in estimations, and estimations with start and stop set are not allowed
-	multiplier := 1 / (float64(end.Sub(*start)) / float64(nextMonth.Sub(*start)))
 
 	clientPrefix := username + "_"
-	query := "round(sum by (exported_service, consumer) (increase(kong_http_requests_total{consumer=~\"" + clientPrefix + ".*\"}[" + end.Sub(*start).Round(time.Second).String() + "]))) != 0"
-
-	resp, w, err := c.prometheus.Query(context.Background(), query, *end)
-	if err != nil {
-		return result, err
-	}
-	if len(w) > 0 {
-		log.Printf("WARNING: prometheus warnings = %#v\n", w)
-	}
-	if resp.Type() != prometheus_model.ValVector {
-		return result, fmt.Errorf("unexpected prometheus response %#v", resp)
-	}
-	values, ok := resp.(prometheus_model.Vector)
-	if !ok {
-		return result, fmt.Errorf("unexpected prometheus response %#v", resp)
-	}
+	selector := "kong_http_requests_total{consumer=~\"" + clientPrefix + ".*\"}"
 
-	for _, element := range values {
-		client := ""
-		service := ""
-		for _, metricLabel := range element.Metric {
-			label := string(metricLabel)
-			if strings.HasPrefix(label, clientPrefix) {
-				client = strings.TrimPrefix(label, clientPrefix)
-			} else {
-				service = label
-			}
+	// insertWithQuery adds the requests returned by the query to the client and service nodes. price returns the
+	// price per 1000 requests of a service and consumer.
+	insertWithQuery := func(query string, ts time.Time, price func(service string, consumer string) float64, add func(node *model.CostWithEstimation, labels prometheus_model.Metric, value float64, price float64)) error {
+		resp, w, err := c.prometheus.Query(context.Background(), query, ts)
+		if err != nil {
+			return err
 		}
-		clientEntry, ok := result.Children[client]
-		if !ok {
-			clientEntry = model.CostWithChildren{
-				CostWithEstimation: model.CostWithEstimation{
-					Month: model.CostEntry{},
-				},
-				Children: map[string]model.CostWithChildren{},
-			}
+		if len(w) > 0 {
+			log.Printf("WARNING: prometheus warnings = %#v\n", w)
+		}
+		if resp.Type() != prometheus_model.ValVector {
+			return fmt.Errorf("unexpected prometheus response %#v", resp)
 		}
-		serviceEntry, ok := clientEntry.Children[service]
+		values, ok := resp.(prometheus_model.Vector)
 		if !ok {
-			serviceEntry = model.CostWithChildren{
-				CostWithEstimation: model.CostWithEstimation{
-					Month: model.CostEntry{},
-				},
-			}
+			return fmt.Errorf("unexpected prometheus response %#v", resp)
 		}
-		value := sampleToFloat(element.Value)
-
-		clientEntry.CostWithEstimation.Month.Requests += value
 
-		serviceEntry.CostWithEstimation.Month.Requests += value
+		for _, element := range values {
+			client := ""
+			service := ""
+			consumer := ""
+			for _, metricLabel := range element.Metric {
+				label := string(metricLabel)
+				if strings.HasPrefix(label, clientPrefix) {
+					consumer = label
+					client = strings.TrimPrefix(label, clientPrefix)
+				} else {
+					service = label
+				}
+			}
+			clientEntry, ok := result.Children[client]
+			if !ok {
+				clientEntry = model.CostWithChildren{
+					CostWithEstimation: model.CostWithEstimation{
+						Month: model.CostEntry{},
+					},
+					Children: map[string]model.CostWithChildren{},
+				}
+			}
+			serviceEntry, ok := clientEntry.Children[service]
+			if !ok {
+				serviceEntry = model.CostWithChildren{
+					CostWithEstimation: model.CostWithEstimation{
+						Month: model.CostEntry{},
+					},
+				}
+			}
+			value := sampleToFloat(element.Value)
+			p := price(service, consumer)
+			add(&clientEntry.CostWithEstimation, element.Metric, value, p)
+			add(&serviceEntry.CostWithEstimation, element.Metric, value, p)
+			add(&result.CostWithEstimation, element.Metric, value, p)
 
-		result.Month.Requests += value
+			clientEntry.Children[service] = serviceEntry
+			result.Children[client] = clientEntry
+		}
+		return nil
+	}
+	entry := func(requests float64, price float64) model.CostEntry {
+		return model.CostEntry{
+			Requests:     requests,
+			RequestsCost: model.ToDecimal(requests / 1000 * price),
+		}
+	}
 
-		if !skipEstimation {
-			estimate := math.Round(value * multiplier)
-			clientEntry.CostWithEstimation.EstimationMonth = model.CostEntry{}
-			clientEntry.CostWithEstimation.EstimationMonth.Requests += estimate
-			serviceEntry.CostWithEstimation.EstimationMonth = model.CostEntry{}
-			serviceEntry.CostWithEstimation.EstimationMonth.Requests += estimate
-			result.EstimationMonth.Requests += estimate
+	for _, segment := range c.getPricingModels().Segments(*start, *end) {
+		query := "round(sum by (exported_service, consumer) (increase(" + selector + "[" + segment.End.Sub(segment.Start).Round(time.Second).String() + "]))) != 0"
+		err = insertWithQuery(query, segment.End, segment.ApiCallPrice, func(node *model.CostWithEstimation, _ prometheus_model.Metric, value float64, price float64) {
+			month := entry(value, price)
+			node.Month.Add(month)
+			if !skipEstimation {
+				addEstimation(node, month, month, month)
+			}
+		})
+		if err != nil {
+			return result, err
 		}
+	}
 
-		clientEntry.Children[service] = serviceEntry
-		result.Children[client] = clientEntry
+	if !skipEstimation {
+		// requests per hour
+		series := "sum by (exported_service, consumer) (increase(" + selector + "[1h]))"
+		deviations, err := c.queryVectorIndex(proj.strategy.deviation(series), proj.start)
+		if err != nil {
+			return result, err
+		}
+		hoursRemaining := proj.hours()
+		err = insertWithQuery(proj.strategy.query(series, proj.start, proj.end), proj.start, func(service string, consumer string) float64 {
+			return c.averagePrice(proj.start, proj.end, func(m model.PricingModel) float64 {
+				return m.ApiCallPrice(service, consumer)
+			})
+		}, func(node *model.CostWithEstimation, labels prometheus_model.Metric, value float64, price float64) {
+			lower, upper := estimationBounds(value, float64(deviations[labels.Fingerprint()]))
+			addEstimation(node, entry(math.Round(lower*hoursRemaining), price), entry(math.Round(value*hoursRemaining), price), entry(math.Round(upper*hoursRemaining), price))
+		})
+		if err != nil {
+			return result, err
+		}
 	}
 	c.logDebug("ApiCallsTree " + time.Since(timer).String())
 
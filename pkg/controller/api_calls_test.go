@@ -0,0 +1,86 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prometheus_model "github.com/prometheus/common/model"
+)
+
+// fakePrometheus answers instant queries with respond and records all queries
+type fakePrometheus struct {
+	v1.API
+	respond      func(query string) prometheus_model.Vector
+	respondRange func(query string, r v1.Range) prometheus_model.Matrix
+	queries      []string
+	ranges       []v1.Range
+}
+
+func (p *fakePrometheus) Query(_ context.Context, query string, _ time.Time, _ ...v1.Option) (prometheus_model.Value, v1.Warnings, error) {
+	p.queries = append(p.queries, query)
+	return p.respond(query), nil, nil
+}
+
+func (p *fakePrometheus) QueryRange(_ context.Context, query string, r v1.Range, _ ...v1.Option) (prometheus_model.Value, v1.Warnings, error) {
+	p.queries = append(p.queries, query)
+	p.ranges = append(p.ranges, r)
+	return p.respondRange(query, r), nil, nil
+}
+
+func TestGetApiCallsTreeEstimationStrategy(t *testing.T) {
+	labels := prometheus_model.Metric{"exported_service": "svc", "consumer": "user_client"}
+	prometheus := &fakePrometheus{respond: func(query string) prometheus_model.Vector {
+		if strings.Contains(query, "offset") {
+			// weekday strategy: 10 requests per hour
+			return prometheus_model.Vector{{Metric: labels, Value: 10}}
+		}
+		if strings.HasPrefix(query, "stddev_over_time") {
+			return prometheus_model.Vector{{Metric: labels, Value: 1}}
+		}
+		return prometheus_model.Vector{{Metric: labels, Value: 100}}
+	}}
+	c := &Controller{prometheus: prometheus, config: &configuration.ConfigStruct{}}
+	c.setPricingModels(model.PricingModelVersions{{ApiCalls: 1}})
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * 24 * time.Hour)
+	tree, err := c.GetApiCallsTree("user", false, model.EstimationOptions{Strategy: model.EstimationStrategyWeekday}, &start, &end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Month.Requests != 100 || !tree.Month.RequestsCost.Equal(model.ToDecimal(0.1)) {
+		t.Errorf("unexpected month %#v", tree.Month)
+	}
+	// 21 remaining days with 10 requests per hour
+	if tree.EstimationMonth.Requests != 100+5040 || !tree.EstimationMonth.RequestsCost.Equal(model.ToDecimal(5.14)) {
+		t.Errorf("unexpected estimation %#v", tree.EstimationMonth)
+	}
+	if tree.Children["client"].Children["svc"].EstimationMonth.Requests != 5140 {
+		t.Errorf("unexpected service estimation %#v", tree.Children["client"].Children["svc"])
+	}
+	// 10 -+ 1.2816 requests per hour
+	if tree.EstimationMonthLower.Requests != 100+4394 || tree.EstimationMonthUpper.Requests != 100+5686 {
+		t.Errorf("unexpected bounds %#v %#v", tree.EstimationMonthLower, tree.EstimationMonthUpper)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/google/uuid"
+)
+
+var ErrNotFound = errors.New("not found")
+
+func parseBudgetThresholds(thresholds string) (result []float64, err error) {
+	for _, s := range strings.Split(thresholds, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		threshold, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid budget threshold %v: %w", s, err)
+		}
+		if threshold <= 0 {
+			return nil, fmt.Errorf("invalid budget threshold %v: must be positive", s)
+		}
+		result = append(result, threshold)
+	}
+	return result, nil
+}
+
+func (c *Controller) GetBudgets(userId string) ([]model.Budget, error) {
+	budgets, err := c.db.ListBudgets()
+	if err != nil {
+		return nil, err
+	}
+	result := []model.Budget{}
+	for _, budget := range budgets {
+		if budget.UserId == userId {
+			result = append(result, budget)
+		}
+	}
+	return result, nil
+}
+
+// GetBudget returns ErrNotFound, if the budget does not exist or belongs to another user
+func (c *Controller) GetBudget(userId string, id string) (model.Budget, error) {
+	budget, found, err := c.db.GetBudget(id)
+	if err != nil {
+		return budget, err
+	}
+	if !found || budget.UserId != userId {
+		return model.Budget{}, ErrNotFound
+	}
+	return budget, nil
+}
+
+func (c *Controller) CreateBudget(userId string, budget model.Budget) (model.Budget, error) {
+	budget.Id = uuid.NewString()
+	budget.UserId = userId
+	err := budget.Validate()
+	if err != nil {
+		return budget, err
+	}
+	return budget, c.db.SetBudget(budget)
+}
+
+func (c *Controller) UpdateBudget(userId string, id string, budget model.Budget) (model.Budget, error) {
+	_, err := c.GetBudget(userId, id)
+	if err != nil {
+		return budget, err
+	}
+	budget.Id = id
+	budget.UserId = userId
+	err = budget.Validate()
+	if err != nil {
+		return budget, err
+	}
+	return budget, c.db.SetBudget(budget)
+}
+
+func (c *Controller) DeleteBudget(userId string, id string) error {
+	_, err := c.GetBudget(userId, id)
+	if err != nil {
+		return err
+	}
+	return c.db.DeleteBudget(id)
+}
+
+func (c *Controller) GetBudgetAlerts(userId string, id string) ([]model.BudgetAlert, error) {
+	_, err := c.GetBudget(userId, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.db.ListBudgetAlerts(id)
+}
+
+// runBudgetChecks checks all budgets immediately and then every interval
+func (c *Controller) runBudgetChecks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := c.checkBudgets()
+		if err != nil {
+			log.Println("ERROR: unable to check budgets, retry in", interval, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkBudgets compares the estimation of the current month with all budgets and sends an alert for each newly
+// reached threshold. Alerts which could not be sent are retried on the next check.
+func (c *Controller) checkBudgets() error {
+	if c.tokenProvider == nil {
+		return fmt.Errorf("missing auth_endpoint config")
+	}
+	budgets, err := c.db.ListBudgets()
+	if err != nil {
+		return err
+	}
+	budgetsByUser := map[string][]model.Budget{}
+	for _, budget := range budgets {
+		budgetsByUser[budget.UserId] = append(budgetsByUser[budget.UserId], budget)
+	}
+	month := c.billing.PeriodName(time.Now())
+	for userId, userBudgets := range budgetsByUser {
+		token, err := c.tokenProvider()
+		if err != nil {
+			return err
+		}
+		tree, err := c.GetCostTree(userId, token, true, false, model.EstimationOptions{}, nil, nil)
+		if err != nil {
+			log.Println("ERROR: unable to calculate cost tree of", userId, "for budget check", err)
+			continue
+		}
+		for _, budget := range userBudgets {
+			estimation := budget.Estimation(tree)
+			for _, threshold := range budget.ReachedThresholds(estimation, c.budgetThresholds) {
+				sent, err := c.db.IsBudgetAlertSent(budget.Id, month, threshold)
+				if err != nil {
+					return err
+				}
+				if sent {
+					continue
+				}
+				alert := model.BudgetAlert{
+					BudgetId:   budget.Id,
+					UserId:     budget.UserId,
+					CostType:   budget.CostType,
+					Month:      month,
+					Threshold:  threshold,
+					Amount:     budget.Amount,
+					Estimation: estimation,
+					Timestamp:  time.Now().UTC(),
+				}
+				err = postWebhook(c.config.BudgetWebhookUrl, alert)
+				if err != nil {
+					log.Println("ERROR: unable to send budget alert", budget.Id, threshold, err)
+					continue
+				}
+				err = c.db.SetBudgetAlert(alert)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
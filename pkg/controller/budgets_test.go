@@ -0,0 +1,37 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseBudgetThresholds(t *testing.T) {
+	thresholds, err := parseBudgetThresholds(" 50, 80,100,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(thresholds, []float64{50, 80, 100}) {
+		t.Fatalf("unexpected thresholds %v", thresholds)
+	}
+	for _, invalid := range []string{"50,0", "-10", "50,abc"} {
+		if _, err = parseBudgetThresholds(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+// CompareCostTrees returns the per node changes of the cost of the user from period a to period b,
+// converted to currency
+func (c *Controller) CompareCostTrees(userId string, token string, admin bool, aStart time.Time, aEnd time.Time, bStart time.Time, bEnd time.Time, currency string) ([]model.CostDelta, error) {
+	a, err := c.GetCostTree(userId, token, admin, true, model.EstimationOptions{}, &aStart, &aEnd)
+	if err != nil {
+		return nil, err
+	}
+	a, err = c.ConvertCostTree(a, currency)
+	if err != nil {
+		return nil, err
+	}
+	b, err := c.GetCostTree(userId, token, admin, true, model.EstimationOptions{}, &bStart, &bEnd)
+	if err != nil {
+		return nil, err
+	}
+	b, err = c.ConvertCostTree(b, currency)
+	if err != nil {
+		return nil, err
+	}
+	return model.CompareCostTrees(a, b), nil
+}
@@ -18,15 +18,22 @@ package controller
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	parsing_api "github.com/SENERGY-Platform/analytics-flow-engine/pkg/parsing-api"
 	serving "github.com/SENERGY-Platform/analytics-serving/client"
 	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/database"
 	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
 	permissions "github.com/SENERGY-Platform/permission-search/lib/client"
+	auth "github.com/SENERGY-Platform/permissions-v2/pkg/client"
 	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 )
@@ -53,11 +60,33 @@ type Controller struct {
 	permClient    permissions.Client
 	servingClient *serving.Client
 
-	pricingModel *model.PricingModel
+	db            *database.Database
+	tokenProvider func() (string, error)
+	pricingModels atomic.Pointer[model.PricingModelVersions]
+	exchangeRates *model.ExchangeRates
+	rounding      model.Rounding
+	invoiceTaxes  []model.InvoiceTax
+	billing       model.BillingCalendar
+
+	budgetThresholds []float64
+
+	processDefinitionCosts    *processDefinitionCostsCacheEntry
+	processDefinitionCostsMux sync.Mutex
 }
 
 func NewController(ctx context.Context, conf configuration.Config, fatal func(err error)) (*Controller, error) {
-	pricingModel, err := model.GetPricingModel(conf.PricingModelFilePath)
+	_, err := model.GetPricingModels(conf.PricingModelFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load pricing model %v: %w", conf.PricingModelFilePath, err)
+	}
+	var pricingModelReloadInterval time.Duration
+	if conf.PricingModelReloadInterval != "" {
+		pricingModelReloadInterval, err = time.ParseDuration(conf.PricingModelReloadInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pricing_model_reload_interval: %w", err)
+		}
+	}
+
 	prometheusClient, err := api.NewClient(api.Config{
 		Address: conf.PrometheusUrl,
 	})
@@ -65,6 +94,67 @@ func NewController(ctx context.Context, conf configuration.Config, fatal func(er
 		return nil, err
 	}
 
+	var exchangeRates *model.ExchangeRates
+	if conf.ExchangeRatesFilePath != "" {
+		rates, err := model.GetExchangeRates(conf.ExchangeRatesFilePath)
+		if err != nil {
+			return nil, err
+		}
+		exchangeRates = &rates
+	}
+
+	rounding := model.Rounding{Mode: conf.RoundingMode, Decimals: int32(conf.RoundingDecimals)}
+	err = rounding.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := database.New(conf.DatabaseFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database %v: %w", conf.DatabaseFilePath, err)
+	}
+	go func() {
+		<-ctx.Done()
+		log.Println("close database", db.Close())
+	}()
+
+	for costType, mode := range conf.BillingModes {
+		switch mode {
+		case model.BillingModeUsage, model.BillingModeRequests, model.BillingModeMax:
+		default:
+			return nil, fmt.Errorf("unknown billing mode %v for cost type %v", mode, costType)
+		}
+	}
+
+	for costType, strategy := range conf.EstimationStrategies {
+		err = model.ValidateEstimationStrategy(strategy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid estimation strategy for cost type %v: %w", costType, err)
+		}
+	}
+
+	billing, err := model.NewBillingCalendar(conf.BillingTimeZone, int(conf.BillingAnchorDay))
+	if err != nil {
+		return nil, fmt.Errorf("invalid billing calendar: %w", err)
+	}
+
+	invoiceTaxes := []model.InvoiceTax{}
+	for name, rate := range conf.InvoiceTaxes {
+		r, err := strconv.ParseFloat(rate, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid invoice tax rate %v: %w", name, err)
+		}
+		invoiceTaxes = append(invoiceTaxes, model.InvoiceTax{Name: name, Rate: r})
+	}
+	slices.SortFunc(invoiceTaxes, func(a, b model.InvoiceTax) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	budgetThresholds, err := parseBudgetThresholds(conf.BudgetThresholds)
+	if err != nil {
+		return nil, err
+	}
+
 	permClient := permissions.NewClient(conf.PermissionsUrl)
 	servingClient := serving.New(conf.ServingUrl)
 
@@ -73,9 +163,54 @@ func NewController(ctx context.Context, conf configuration.Config, fatal func(er
 		prometheus:    v1.NewAPI(prometheusClient),
 		permClient:    permClient,
 		servingClient: servingClient,
-		pricingModel:  &pricingModel,
+		db:            db,
+		exchangeRates: exchangeRates,
+		rounding:      rounding,
+		invoiceTaxes:  invoiceTaxes,
+		billing:       billing,
 		flowCache:     map[string]flowCacheEntry{}, flowCacheMux: sync.Mutex{},
+
+		budgetThresholds: budgetThresholds,
+	}
+	err = controller.initPricingModels()
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize pricing model: %w", err)
+	}
+	if conf.AuthEndpoint != "" {
+		controller.tokenProvider = auth.NewTokenProvider(conf.AuthEndpoint, conf.AuthClientId, conf.AuthClientSecret)
+	}
+	if conf.SnapshotsEnabled {
+		snapshotInterval, err := time.ParseDuration(conf.SnapshotInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot_interval: %w", err)
+		}
+		go controller.runSnapshots(ctx, snapshotInterval)
+	}
+	if conf.BudgetsEnabled {
+		if conf.BudgetWebhookUrl == "" {
+			return nil, fmt.Errorf("missing budget_webhook_url config")
+		}
+		budgetInterval, err := time.ParseDuration(conf.BudgetInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid budget_interval: %w", err)
+		}
+		go controller.runBudgetChecks(ctx, budgetInterval)
+	}
+	if conf.AnomaliesEnabled {
+		anomalyInterval, err := time.ParseDuration(conf.AnomalyInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid anomaly_interval: %w", err)
+		}
+		go controller.runAnomalyDetection(ctx, anomalyInterval)
+	}
+	if conf.EstimationRecordsEnabled {
+		estimationRecordInterval, err := time.ParseDuration(conf.EstimationRecordInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid estimation_record_interval: %w", err)
+		}
+		go controller.runEstimationRecords(ctx, estimationRecordInterval)
 	}
+	go controller.watchPricingModel(ctx, pricingModelReloadInterval)
 
 	return controller, nil
 }
@@ -18,33 +18,114 @@ package controller
 
 import (
 	"errors"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
 )
 
-func (c *Controller) GetCostControllers(userid string, token string, admin bool, costType model.CostType, skipEstimation bool, start *time.Time, end *time.Time) (res model.CostWithChildren, err error) {
+// GetCostControllers returns the cost of a single cost type of the user. If the pricing model has tiers, they are
+// evaluated against the whole tree like in GetCostTree and the cost type receives its share of the tier adjustment
+// as child "tiers", so that the cost types add up to the tree.
+func (c *Controller) GetCostControllers(userid string, token string, admin bool, costType model.CostType, skipEstimation bool, estimation model.EstimationOptions, start *time.Time, end *time.Time) (res model.CostWithChildren, err error) {
+	if !slices.Contains(treeCostTypes, costType) {
+		return res, errors.New("unknown costType")
+	}
+	ts := time.Now()
+	if end != nil {
+		ts = *end
+	}
+	if len(c.getPricingModels().At(ts).Tiers) > 0 {
+		tree, err := c.GetCostTree(userid, token, admin, skipEstimation, estimation, start, end)
+		if err != nil {
+			return res, err
+		}
+		res = tree[costType]
+		share := tree.TierShare(costType)
+		if !share.Month.Total().IsZero() || !share.EstimationMonth.Total().IsZero() {
+			children := map[string]model.CostWithChildren{}
+			for k, v := range res.Children {
+				children[k] = v
+			}
+			children[model.CostTypeTiers] = model.CostWithChildren{CostWithEstimation: share}
+			res.Children = children
+			res.Add(share)
+		}
+		return c.finalize(res, ts), nil
+	}
+	if rangeStart, rangeEnd, split := c.splitRange(skipEstimation, start, end); split {
+		tree, ok, err := c.getCostTreeByPeriods(userid, rangeStart, rangeEnd, !skipEstimation, func(start time.Time, end time.Time, estimate bool) (model.CostTree, error) {
+			res, err := c.getCostControllers(userid, token, admin, costType, !estimate, estimation, &start, &end)
+			return model.CostTree{costType: res}, err
+		})
+		if ok || err != nil {
+			return tree[costType], err
+		}
+	}
+	return c.getCostControllers(userid, token, admin, costType, skipEstimation, estimation, start, end)
+}
+
+// treeCostTypes are the cost types, which can be requested separately
+var treeCostTypes = []model.CostType{model.CostTypeAnalytics, model.CostTypeImports, model.CostTypeProcesses, model.CostTypeApiCalls, model.CostTypeDevices, model.CostTypeExports}
+
+func (c *Controller) getCostControllers(userid string, token string, admin bool, costType model.CostType, skipEstimation bool, estimation model.EstimationOptions, start *time.Time, end *time.Time) (res model.CostWithChildren, err error) {
 	switch costType {
 	case model.CostTypeAnalytics:
-		return c.GetAnalyticsTree(userid, skipEstimation, start, end)
+		res, err = c.GetAnalyticsTree(userid, skipEstimation, estimation, start, end)
 	case model.CostTypeImports:
-		return c.GetImportsTree(userid, skipEstimation, start, end)
+		res, err = c.GetImportsTree(userid, skipEstimation, estimation, start, end)
 	case model.CostTypeProcesses:
-		return c.GetProcessTree(userid, skipEstimation, start, end)
+		res, err = c.GetProcessTree(userid, skipEstimation, estimation, start, end)
 	case model.CostTypeApiCalls:
-		return c.GetApiCallsTree(userid, skipEstimation, start, end)
+		res, err = c.GetApiCallsTree(userid, skipEstimation, estimation, start, end)
 	case model.CostTypeDevices:
-		return c.GetDevicesTree(userid, token, skipEstimation, start, end)
+		res, err = c.GetDevicesTree(userid, token, skipEstimation, estimation, start, end)
 	case model.CostTypeExports:
-		return c.GetExportsTree(userid, token, admin, skipEstimation, start, end)
+		res, err = c.GetExportsTree(userid, token, admin, skipEstimation, estimation, start, end)
 	default:
 		return res, errors.New("unknown costType")
 	}
+	if err != nil {
+		return res, err
+	}
+	ts := time.Now()
+	if end != nil {
+		ts = *end
+	}
+	return c.finalize(res, ts), nil
+}
 
+// GetCostTree returns the cost of the user. If start is provided, the time range is calculated per billing period and
+// closed months, which are fully covered by start and end, are read from snapshots. With estimation, only the last
+// period is projected.
+func (c *Controller) GetCostTree(userid string, token string, admin bool, skipEstimation bool, estimation model.EstimationOptions, start *time.Time, end *time.Time) (res model.CostTree, err error) {
+	if rangeStart, rangeEnd, split := c.splitRange(skipEstimation, start, end); split {
+		res, ok, err := c.getCostTreeByPeriods(userid, rangeStart, rangeEnd, !skipEstimation, func(start time.Time, end time.Time, estimate bool) (model.CostTree, error) {
+			return c.getCostTreeWithTiers(userid, token, admin, !estimate, estimation, &start, &end)
+		})
+		if ok || err != nil {
+			return res, err
+		}
+	}
+	return c.getCostTreeWithTiers(userid, token, admin, skipEstimation, estimation, start, end)
+}
+
+// splitRange returns the time range, which can be split per billing period. Returns false, if the request has to be
+// calculated as a whole, e.g. if it is invalid or uses the default time range.
+func (c *Controller) splitRange(skipEstimation bool, start *time.Time, end *time.Time) (time.Time, time.Time, bool) {
+	if start == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	start, end, err := c.checkStartEnd(skipEstimation, start, end)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return *start, *end, true
 }
 
-func (c *Controller) GetCostTree(userid string, token string, admin bool, skipEstimation bool, start *time.Time, end *time.Time) (res model.CostTree, err error) {
+// getCostTree calculates the cost tree without tiers and without rounding, see getCostTreeWithTiers
+func (c *Controller) getCostTree(userid string, token string, admin bool, skipEstimation bool, estimation model.EstimationOptions, start *time.Time, end *time.Time) (res model.CostTree, err error) {
 	res = model.CostTree{}
 	mux := sync.Mutex{}
 	wg := sync.WaitGroup{}
@@ -53,12 +134,12 @@ func (c *Controller) GetCostTree(userid string, token string, admin bool, skipEs
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		analyticsTree, err := c.GetAnalyticsTree(userid, skipEstimation, start, end)
+		analyticsTree, err := c.GetAnalyticsTree(userid, skipEstimation, estimation, start, end)
 		if err != nil {
 			superErr = err
 			return
 		}
-		if analyticsTree.Month.Cpu != 0 || analyticsTree.Month.Ram != 0 || analyticsTree.Month.Storage != 0 {
+		if !analyticsTree.Month.Cpu.IsZero() || !analyticsTree.Month.Ram.IsZero() || !analyticsTree.Month.Storage.IsZero() || !analyticsTree.Month.Network.IsZero() {
 			res[model.CostTypeAnalytics] = analyticsTree
 		}
 	}()
@@ -66,12 +147,12 @@ func (c *Controller) GetCostTree(userid string, token string, admin bool, skipEs
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		importsTree, err := c.GetImportsTree(userid, skipEstimation, start, end)
+		importsTree, err := c.GetImportsTree(userid, skipEstimation, estimation, start, end)
 		if err != nil {
 			superErr = err
 			return
 		}
-		if importsTree.Month.Cpu != 0 || importsTree.Month.Ram != 0 || importsTree.Month.Storage != 0 {
+		if !importsTree.Month.Cpu.IsZero() || !importsTree.Month.Ram.IsZero() || !importsTree.Month.Storage.IsZero() || !importsTree.Month.Network.IsZero() {
 			res[model.CostTypeImports] = importsTree
 		}
 	}()
@@ -79,12 +160,12 @@ func (c *Controller) GetCostTree(userid string, token string, admin bool, skipEs
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		processTree, err := c.GetProcessTree(userid, skipEstimation, start, end)
+		processTree, err := c.GetProcessTree(userid, skipEstimation, estimation, start, end)
 		if err != nil {
 			superErr = err
 			return
 		}
-		if processTree.Month.Cpu != 0 || processTree.Month.Ram != 0 || processTree.Month.Storage != 0 {
+		if !processTree.Month.Cpu.IsZero() || !processTree.Month.Ram.IsZero() || !processTree.Month.Storage.IsZero() || !processTree.Month.Network.IsZero() {
 			mux.Lock()
 			res[model.CostTypeProcesses] = processTree
 			mux.Unlock()
@@ -98,7 +179,7 @@ func (c *Controller) GetCostTree(userid string, token string, admin bool, skipEs
 		if err != nil {
 			return
 		}
-		apiCallsTree, err := c.GetApiCallsTree(username, skipEstimation, start, end)
+		apiCallsTree, err := c.GetApiCallsTree(username, skipEstimation, estimation, start, end)
 		if err != nil {
 			superErr = err
 			return
@@ -111,12 +192,12 @@ func (c *Controller) GetCostTree(userid string, token string, admin bool, skipEs
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		devicesTree, err := c.GetDevicesTree(userid, token, skipEstimation, start, end)
+		devicesTree, err := c.GetDevicesTree(userid, token, skipEstimation, estimation, start, end)
 		if err != nil {
 			superErr = err
 			return
 		}
-		if devicesTree.Month.Cpu != 0 || devicesTree.Month.Ram != 0 || devicesTree.Month.Storage != 0 || devicesTree.Month.Requests != 0 {
+		if !devicesTree.Month.Cpu.IsZero() || !devicesTree.Month.Ram.IsZero() || !devicesTree.Month.Storage.IsZero() || devicesTree.Month.Requests != 0 {
 			mux.Lock()
 			res["Devices"] = devicesTree
 			mux.Unlock()
@@ -126,12 +207,12 @@ func (c *Controller) GetCostTree(userid string, token string, admin bool, skipEs
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		exportsTree, err := c.GetExportsTree(userid, token, admin, skipEstimation, start, end)
+		exportsTree, err := c.GetExportsTree(userid, token, admin, skipEstimation, estimation, start, end)
 		if err != nil {
 			superErr = err
 			return
 		}
-		if exportsTree.Month.Cpu != 0 || exportsTree.Month.Ram != 0 || exportsTree.Month.Storage != 0 {
+		if !exportsTree.Month.Cpu.IsZero() || !exportsTree.Month.Ram.IsZero() || !exportsTree.Month.Storage.IsZero() || !exportsTree.Month.Network.IsZero() {
 			mux.Lock()
 			res["Exports"] = exportsTree
 			mux.Unlock()
@@ -139,5 +220,19 @@ func (c *Controller) GetCostTree(userid string, token string, admin bool, skipEs
 	}()
 
 	wg.Wait()
-	return res, superErr
+	if superErr != nil {
+		return res, superErr
+	}
+
+	if c.config.OverheadEnabled {
+		overheadTree, err := c.GetOverheadTree(res, skipEstimation, estimation, start, end)
+		if err != nil {
+			return res, err
+		}
+		if !overheadTree.Month.Cpu.IsZero() || !overheadTree.Month.Ram.IsZero() {
+			res[model.CostTypeOverhead] = overheadTree
+		}
+	}
+
+	return res, nil
 }
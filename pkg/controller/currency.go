@@ -0,0 +1,60 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"errors"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+// finalize rounds the tree according to the configured rounding rule and labels it with the currency of the pricing model
+func (c *Controller) finalize(tree model.CostWithChildren, ts time.Time) model.CostWithChildren {
+	tree = tree.Round(c.rounding)
+	tree.Currency = c.getPricingModels().At(ts).Currency
+	return tree
+}
+
+// ConvertCostWithChildren converts the tree into another currency using the configured exchange rates
+func (c *Controller) ConvertCostWithChildren(tree model.CostWithChildren, currency string) (model.CostWithChildren, error) {
+	if currency == "" || currency == tree.Currency {
+		return tree, nil
+	}
+	if c.exchangeRates == nil {
+		return tree, errors.New("no exchange rates configured")
+	}
+	rate, err := c.exchangeRates.Rate(tree.Currency, currency)
+	if err != nil {
+		return tree, err
+	}
+	tree = tree.Convert(rate).Round(c.rounding)
+	tree.Currency = currency
+	return tree, nil
+}
+
+// ConvertCostTree converts all nodes of the tree into another currency using the configured exchange rates
+func (c *Controller) ConvertCostTree(tree model.CostTree, currency string) (result model.CostTree, err error) {
+	result = model.CostTree{}
+	for k, v := range tree {
+		result[k], err = c.ConvertCostWithChildren(v, currency)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
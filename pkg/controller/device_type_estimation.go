@@ -0,0 +1,133 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	permissions "github.com/SENERGY-Platform/permission-search/lib/client"
+)
+
+// GetDeviceTypeEstimation estimates the monthly cost of count devices of the device type from the cost of the
+// existing devices of the device type in the last billing period. Like GetDevicesTree, only storage and message
+// cost is considered. Devices of all users are used, if a service token is available.
+func (c *Controller) GetDeviceTypeEstimation(token string, deviceTypeId string, count int64) (estimation model.DeviceTypeEstimation, err error) {
+	if c.tokenProvider != nil {
+		token, err = c.tokenProvider()
+		if err != nil {
+			return estimation, err
+		}
+	}
+	start, end, err := c.billing.Preset(model.PeriodLastMonth, time.Now())
+	if err != nil {
+		return estimation, err
+	}
+	pricingModels := c.getPricingModels()
+	storagePriceHours := pricingModels.PriceHours(start, end, timescaleStoragePrice(model.CostTypeDevices))
+	durationPassed := end.Sub(start).Round(time.Second)
+
+	deviceIds := []string{}
+	storage := map[string]float64{}  // cost by device id
+	messages := map[string]float64{} // cost by device id
+
+	limit := 0
+	found := 0
+	var after *permissions.ListAfter
+	for found == limit {
+		limit = 5000
+		pageIds, tables, err := c.queryDevicePage(token, permissions.ConditionConfig{
+			Feature:   "features.device_type_id",
+			Value:     deviceTypeId,
+			Operation: permissions.QueryEqualOperation,
+		}, limit, after)
+		if err != nil {
+			return estimation, err
+		}
+		found = len(pageIds)
+		if found == 0 {
+			break
+		}
+		deviceIds = append(deviceIds, pageIds...)
+		after = &permissions.ListAfter{
+			Id: pageIds[found-1],
+		}
+
+		promQuery := "avg_over_time(table:timescale_table_size_bytes:avg_1h{table=~\"" + strings.Join(tables, "|") + "\"}[" + durationPassed.String() + ":])"
+		promResp, w, err := c.prometheus.Query(context.Background(), promQuery, end)
+		if err != nil {
+			return estimation, err
+		}
+		values, err := validateAndGetValuesPromResponse(promResp, w)
+		if err != nil {
+			return estimation, err
+		}
+		for _, element := range values {
+			table := string(element.Metric["table"])
+			matches := deviceTableMatch.FindAllStringSubmatch(table, -1)
+			if matches == nil || len(matches[0]) != 3 {
+				return estimation, fmt.Errorf("received metric for unexpected table name %#v", table)
+			}
+			id, err := models.LongId(matches[0][1])
+			if err != nil {
+				return estimation, err
+			}
+			storage[deviceIdPrefix+id] += storagePriceHours * sampleToFloat(element.Value) / 1000000000 // cost * hours * avg-size / correction-bytes-in-gb
+		}
+
+		for _, segment := range pricingModels.Segments(start, end) {
+			promResp, w, err := c.prometheus.Query(context.Background(), c.deviceMessagesQuery(pageIds, segment.End.Sub(segment.Start)), segment.End)
+			if err != nil {
+				return estimation, err
+			}
+			values, err := validateAndGetValuesPromResponse(promResp, w)
+			if err != nil {
+				return estimation, err
+			}
+			for _, element := range values {
+				messages[string(element.Metric["device_id"])] += sampleToFloat(element.Value) / 1000 * segment.DeviceMessagePrice(c.deviceMessageType(element.Metric))
+			}
+		}
+	}
+	if len(deviceIds) == 0 {
+		return estimation, fmt.Errorf("%w: no devices of device type %v", ErrNotFound, deviceTypeId)
+	}
+
+	storageCosts := []float64{}
+	messageCosts := []float64{}
+	totalCosts := []float64{}
+	for _, id := range deviceIds {
+		storageCosts = append(storageCosts, storage[id])
+		messageCosts = append(messageCosts, messages[id])
+		totalCosts = append(totalCosts, storage[id]+messages[id])
+	}
+	estimate := func(costs []float64) model.Estimation {
+		min, max, mean, median := calcMinMaxMeanMedian(costs)
+		return model.Estimation{Min: min, Max: max, Mean: mean, Median: median}.Scale(float64(count))
+	}
+	return model.DeviceTypeEstimation{
+		Devices:  len(deviceIds),
+		Count:    count,
+		Storage:  estimate(storageCosts),
+		Messages: estimate(messageCosts),
+		Total:    estimate(totalCosts),
+	}, nil
+}
@@ -0,0 +1,96 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	permissions "github.com/SENERGY-Platform/permission-search/lib/client"
+	prometheus_model "github.com/prometheus/common/model"
+)
+
+// fakePermissions answers the first page of a query with devices
+type fakePermissions struct {
+	permissions.Client
+	devices []string
+}
+
+func (p *fakePermissions) Query(_ string, query permissions.QueryMessage) (interface{}, int, error) {
+	result := []interface{}{}
+	if query.Find.After == nil {
+		for _, id := range p.devices {
+			result = append(result, map[string]interface{}{"id": id})
+		}
+	}
+	return result, 200, nil
+}
+
+func TestGetDeviceTypeEstimation(t *testing.T) {
+	a := deviceIdPrefix + "7d1e2f3a-0000-4000-8000-000000000001"
+	b := deviceIdPrefix + "7d1e2f3a-0000-4000-8000-000000000002"
+	table := func(id string) prometheus_model.Metric {
+		short, err := models.ShortenId(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return prometheus_model.Metric{"table": prometheus_model.LabelValue("device:" + short + "_service:aaaaaaaaaaaaaaaaaaaaaa")}
+	}
+	prometheus := &fakePrometheus{respond: func(query string) prometheus_model.Vector {
+		if strings.HasPrefix(query, "avg_over_time(table:timescale_table_size_bytes:avg_1h") {
+			return prometheus_model.Vector{{Metric: table(a), Value: 2000000000}}
+		}
+		return prometheus_model.Vector{
+			{Metric: prometheus_model.Metric{"device_id": prometheus_model.LabelValue(a)}, Value: 1000},
+			{Metric: prometheus_model.Metric{"device_id": prometheus_model.LabelValue(b)}, Value: 3000},
+		}
+	}}
+	c := &Controller{prometheus: prometheus, permClient: &fakePermissions{devices: []string{a, b}}, config: &configuration.ConfigStruct{}}
+	c.setPricingModels(model.PricingModelVersions{{Storage: 1, DeviceMessages: 1}})
+
+	estimation, err := c.GetDeviceTypeEstimation("token", "device-type", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, end, err := c.billing.Preset(model.PeriodLastMonth, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hours := end.Sub(start).Hours()
+	equal := func(actual float64, expected float64) bool {
+		return math.Abs(actual-expected) < 1e-9
+	}
+	if estimation.Devices != 2 || estimation.Count != 10 {
+		t.Errorf("unexpected devices %#v", estimation)
+	}
+	// device a stores 2 GB, device b nothing
+	if !equal(estimation.Storage.Min, 0) || !equal(estimation.Storage.Max, 10*2*hours) || !equal(estimation.Storage.Mean, 10*hours) {
+		t.Errorf("unexpected storage estimation %#v", estimation.Storage)
+	}
+	// 1000 and 3000 messages
+	if !equal(estimation.Messages.Min, 10) || !equal(estimation.Messages.Max, 30) || !equal(estimation.Messages.Mean, 20) {
+		t.Errorf("unexpected messages estimation %#v", estimation.Messages)
+	}
+	if !equal(estimation.Total.Min, 30) || !equal(estimation.Total.Max, 10*(2*hours+1)) {
+		t.Errorf("unexpected total estimation %#v", estimation.Total)
+	}
+}
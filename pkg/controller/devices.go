@@ -23,7 +23,6 @@ import (
 	"log"
 	"net/http"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -42,88 +41,53 @@ const deviceIdPrefix = "urn:infai:ses:device:"
 	- Device cost only considers storage cost.
 */
 
-func (c *Controller) GetDevicesTree(userId string, token string, skipEstimation bool, start *time.Time, end *time.Time) (result model.CostWithChildren, err error) {
+func (c *Controller) GetDevicesTree(userId string, token string, skipEstimation bool, estimation model.EstimationOptions, start *time.Time, end *time.Time) (result model.CostWithChildren, err error) {
 	timer := time.Now()
 
-	if (start == nil && end != nil) || (start != nil && end == nil) || (start != nil && !skipEstimation) {
-		return result, fmt.Errorf("must not provide only one of start or end. must not provide start and stop without skipEstimation")
+	start, end, err = c.checkStartEnd(skipEstimation, start, end)
+	if err != nil {
+		return result, err
 	}
-	if start == nil {
-		start, end = defaultStartEnd()
+	var proj *projection
+	if !skipEstimation {
+		proj, err = c.newProjection(model.CostTypeDevices, estimation, *end)
+		if err != nil {
+			return result, err
+		}
 	}
 	result = model.CostWithChildren{
 		CostWithEstimation: model.CostWithEstimation{},
 		Children:           map[string]model.CostWithChildren{},
 	}
+	pricingModels := c.getPricingModels()
 
 	limit := 0
 	found := 0
-	var deviceList []interface{} = []interface{}{}
 	var after *permissions.ListAfter
 
 	for found == limit {
 		limit = 5000
-		query := permissions.QueryMessage{
-			Resource: "devices",
-			Find: &permissions.QueryFind{
-				QueryListCommons: permissions.QueryListCommons{
-					Offset:   0,
-					Limit:    limit,
-					After:    after,
-					SortBy:   "id",
-					SortDesc: true,
-				},
-				Filter: &permissions.Selection{
-					Condition: permissions.ConditionConfig{
-						Feature:   "features.owner_id",
-						Value:     userId,
-						Operation: permissions.QueryEqualOperation,
-					},
-				},
-			}}
-		res, code, err := c.permClient.Query(token, query)
+		deviceIds, tables, err := c.queryDevicePage(token, permissions.ConditionConfig{
+			Feature:   "features.owner_id",
+			Value:     userId,
+			Operation: permissions.QueryEqualOperation,
+		}, limit, after)
 		if err != nil {
 			return result, err
 		}
-		if code != http.StatusOK {
-			return result, errors.New("unexpected upstream status code")
+		if deviceIds == nil {
+			return result, nil
 		}
-		if res == nil {
-			return result, err
-		}
-		ok := false
-		deviceList, ok = res.([]interface{})
-		if !ok {
-			return result, errUnexpectedReponseFormat
-		}
-		found = len(deviceList)
-
-		tables := []string{}
-		deviceIds := []string{}
+		found = len(deviceIds)
 		deviceId := ""
-		for _, device := range deviceList {
-			deviceMap, ok := device.(map[string]interface{})
-			if !ok {
-				return result, errUnexpectedReponseFormat
-			}
-			deviceId, ok = deviceMap["id"].(string)
-			if !ok {
-				return result, errUnexpectedReponseFormat
-			}
-			deviceIds = append(deviceIds, deviceId)
-			shortDeviceId, err := models.ShortenId(deviceId)
-			if err != nil {
-				return result, err
-			}
-			tables = append(tables, "device:"+shortDeviceId+".*")
+		if found > 0 {
+			deviceId = deviceIds[found-1]
 		}
 		after = &permissions.ListAfter{
 			Id: deviceId,
 		}
 
-		tableSizeByteMap := map[string]float64{}
-
-		insertWithQuery := func(promQuery string, metricName prometheus_model.LabelName, ts time.Time, callback func(metricValue string, value float64, child *model.CostWithChildren)) error {
+		insertWithQuery := func(promQuery string, metricName prometheus_model.LabelName, ts time.Time, callback func(metricValue string, labels prometheus_model.Metric, value float64, child *model.CostWithChildren)) error {
 			resp, w, err := c.prometheus.Query(context.Background(), promQuery, ts)
 			if err != nil {
 				return err
@@ -171,78 +135,200 @@ func (c *Controller) GetDevicesTree(userId string, token string, skipEstimation
 						},
 					}
 				}
-				callback(metricStr, val, &child)
+				callback(metricStr, element.Metric, val, &child)
 				result.Children[id] = child
 			}
 			return nil
 		}
 
-		durationPassed := end.Sub(*start).Round(time.Second)
-		now := time.Now() // This is fine as getting a prediction and providing start and end times is not allowed
-		endOfMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC)
-		durationRemaining := endOfMonth.Sub(now)
+		tableSeries := "table:timescale_table_size_bytes:avg_1h{table=~\"" + strings.Join(tables, "|") + "\"}"
 
-		// Costs in current month
+		// Storage in current month, split at pricing model boundaries
 		timer2 := time.Now()
-		promQuery := "avg_over_time(table:timescale_table_size_bytes:avg_1h{table=~\"" + strings.Join(tables, "|") + "\"}[" + durationPassed.String() + ":])"
-		err = insertWithQuery(promQuery, "table", *end, func(table string, value float64, child *model.CostWithChildren) {
-			tableSizeBytesEstimation := value
-			tableSizeBytes, ok := tableSizeByteMap[table]
-			if !ok {
-				tableSizeBytes = 0
+		for _, segment := range pricingModels.Segments(*start, *end) {
+			durationPassed := segment.End.Sub(segment.Start).Round(time.Second)
+			priceHours := timescaleStoragePrice(model.CostTypeDevices)(segment.PricingModel) * durationPassed.Hours()
+			err = insertWithQuery("avg_over_time("+tableSeries+"["+durationPassed.String()+":])", "table", segment.End, func(_ string, _ prometheus_model.Metric, tableSizeBytes float64, child *model.CostWithChildren) {
+				month := model.CostEntry{
+					Storage:        model.ToDecimal(priceHours * tableSizeBytes / 1000000000), // cost * hours-progressed * avg-size / correction-bytes-in-gb
+					StorageGbHours: tableSizeBytes * durationPassed.Hours() / 1000000000,
+				}
+				child.Month.Add(month)
+				result.Month.Add(month)
+				if !skipEstimation {
+					addEstimation(&child.CostWithEstimation, month, month, month)
+					addEstimation(&result.CostWithEstimation, month, month, month)
+				}
+			})
+			if err != nil {
+				return result, err
 			}
+		}
+		c.logDebug("DevicesTree: Storage " + time.Since(timer2).String())
 
-			if !skipEstimation {
-				avgFutureTableSize := (tableSizeBytesEstimation + tableSizeBytes) / 2
-				futureCost := c.pricingModel.Storage * avgFutureTableSize * durationRemaining.Hours() / 1000000000 // cost * avg-size * hours-progressed / correction-bytes-in-gb
-				child.CostWithEstimation.EstimationMonth.Storage += futureCost
-				result.CostWithEstimation.EstimationMonth.Storage += futureCost
+		// Requests
+		timer2 = time.Now()
+		for _, segment := range pricingModels.Segments(*start, *end) {
+			err = insertWithQuery(c.deviceMessagesQuery(deviceIds, segment.End.Sub(segment.Start)), "device_id", segment.End, func(_ string, labels prometheus_model.Metric, value float64, child *model.CostWithChildren) {
+				month := model.CostEntry{
+					Requests:     value,
+					RequestsCost: model.ToDecimal(value / 1000 * segment.DeviceMessagePrice(c.deviceMessageType(labels))),
+				}
+				child.Month.Add(month)
+				result.Month.Add(month)
+				if !skipEstimation {
+					addEstimation(&child.CostWithEstimation, month, month, month)
+					addEstimation(&result.CostWithEstimation, month, month, month)
+				}
+			})
+			if err != nil {
+				return result, err
 			}
-		})
-		if err != nil {
-			return result, err
 		}
-		c.logDebug("DevicesTree: Current Month " + time.Since(timer2).String())
+		c.logDebug("DevicesTree: Requests " + time.Since(timer2).String())
 
 		// Estimations
 		if !skipEstimation {
 			timer2 = time.Now()
-			promQuery = "predict_linear(table:timescale_table_size_bytes:avg_1h{table=~\"" + strings.Join(tables, "|") + "\"}[24h:], " + strconv.FormatFloat(durationRemaining.Seconds(), 'f', 0, 64) + ")"
-			err = insertWithQuery(promQuery, "table", time.Now(), func(table string, value float64, child *model.CostWithChildren) {
-				existingTableSizeBytes, ok := tableSizeByteMap[table]
-				if !ok {
-					existingTableSizeBytes = 0
+			hoursRemaining := proj.hours()
+			storagePriceHoursRemaining := pricingModels.PriceHours(proj.start, proj.end, timescaleStoragePrice(model.CostTypeDevices))
+			tableDeviations, err := c.queryVectorIndex(proj.strategy.deviation(tableSeries), proj.start)
+			if err != nil {
+				return result, err
+			}
+			futureStorage := func(tableSizeBytes float64) model.CostEntry {
+				return model.CostEntry{
+					Storage:        model.ToDecimal(storagePriceHoursRemaining * tableSizeBytes / 1000000000), // cost * hours-remaining * avg-size / correction-bytes-in-gb
+					StorageGbHours: tableSizeBytes * hoursRemaining / 1000000000,
 				}
-				tableSizeByteMap[table] = existingTableSizeBytes + value
-				additionalCost := c.pricingModel.Storage * value * durationPassed.Hours() / 1000000000 // cost * avg-size * hours-remaining / correction-bytes-in-gb
-				child.CostWithEstimation.Month.Storage += additionalCost
-				result.CostWithEstimation.Month.Storage += additionalCost
-				child.CostWithEstimation.EstimationMonth.Storage += additionalCost
+			}
+			err = insertWithQuery(proj.strategy.query(tableSeries, proj.start, proj.end), "table", proj.start, func(_ string, labels prometheus_model.Metric, tableSizeBytes float64, child *model.CostWithChildren) {
+				lower, upper := estimationBounds(tableSizeBytes, float64(tableDeviations[labels.Fingerprint()]))
+				addEstimation(&child.CostWithEstimation, futureStorage(lower), futureStorage(tableSizeBytes), futureStorage(upper))
+				addEstimation(&result.CostWithEstimation, futureStorage(lower), futureStorage(tableSizeBytes), futureStorage(upper))
+			})
+			if err != nil {
+				return result, err
+			}
+			messagesSeries := c.deviceMessagesSeries(deviceIds)
+			messageDeviations, err := c.queryVectorIndex(proj.strategy.deviation(messagesSeries), proj.start)
+			if err != nil {
+				return result, err
+			}
+			err = insertWithQuery(proj.strategy.query(messagesSeries, proj.start, proj.end), "device_id", proj.start, func(_ string, labels prometheus_model.Metric, messagesPerHour float64, child *model.CostWithChildren) {
+				price := c.averagePrice(proj.start, proj.end, deviceMessagePrice(c.deviceMessageType(labels)))
+				futureMessages := func(messagesPerHour float64) model.CostEntry {
+					messages := messagesPerHour * hoursRemaining
+					return model.CostEntry{
+						Requests:     messages,
+						RequestsCost: model.ToDecimal(messages / 1000 * price),
+					}
+				}
+				lower, upper := estimationBounds(messagesPerHour, float64(messageDeviations[labels.Fingerprint()]))
+				addEstimation(&child.CostWithEstimation, futureMessages(lower), futureMessages(messagesPerHour), futureMessages(upper))
+				addEstimation(&result.CostWithEstimation, futureMessages(lower), futureMessages(messagesPerHour), futureMessages(upper))
 			})
 			if err != nil {
 				return result, err
 			}
 			c.logDebug("DevicesTree: Estimations " + time.Since(timer2).String())
 		}
+	}
 
-		// Requests
-		timer2 = time.Now()
-		nextMonth := time.Date(time.Now().Year(), time.Now().Month()+1, 0, 0, 0, 0, 0, time.UTC) // this is okay, because multiplier is only used in estimations, and estimations with start and stop set are not allowed
-		multiplier := 1 / (float64(end.Sub(*start)) / float64(nextMonth.Sub(*start)))
-		promQuery = "round(sum_over_time(device_id:connector_source_received_device_msg_size_count:sum_increase_1h{device_id=~\"" + strings.Join(deviceIds, "|") + "\"}[" + durationPassed.String() + "])) != 0"
-		err = insertWithQuery(promQuery, "device_id", *end, func(table string, value float64, child *model.CostWithChildren) {
-			child.Month.Requests = value
-			result.CostWithEstimation.Month.Requests += child.Month.Requests
-			if !skipEstimation {
-				child.EstimationMonth.Requests = child.Month.Requests * multiplier
-				result.CostWithEstimation.EstimationMonth.Requests += child.EstimationMonth.Requests
-			}
-		})
+	c.logDebug("DevicesTree " + time.Since(timer).String())
+	return
+}
+
+// deviceMessagesQuery returns a query for the number of messages per device and message type within the duration
+func (c *Controller) deviceMessagesQuery(deviceIds []string, duration time.Duration) string {
+	return "round(sum by (" + c.deviceMessagesBy() + ") (sum_over_time(" + deviceMessagesMetric(deviceIds) + "[" + duration.Round(time.Second).String() + "]))) != 0"
+}
+
+// deviceMessagesSeries returns the series of the messages per hour of each device and message type
+func (c *Controller) deviceMessagesSeries(deviceIds []string) string {
+	return "sum by (" + c.deviceMessagesBy() + ") (" + deviceMessagesMetric(deviceIds) + ")"
+}
+
+func deviceMessagesMetric(deviceIds []string) string {
+	return "device_id:connector_source_received_device_msg_size_count:sum_increase_1h{device_id=~\"" + strings.Join(deviceIds, "|") + "\"}"
+}
+
+func (c *Controller) deviceMessagesBy() string {
+	by := "device_id"
+	if c.config.DeviceMessageTypeLabel != "" {
+		by += ", " + c.config.DeviceMessageTypeLabel
+	}
+	return by
+}
+
+// deviceMessageType returns the message type of a device message series, empty if no type label is configured
+func (c *Controller) deviceMessageType(labels prometheus_model.Metric) string {
+	if c.config.DeviceMessageTypeLabel == "" {
+		return ""
+	}
+	return string(labels[prometheus_model.LabelName(c.config.DeviceMessageTypeLabel)])
+}
+
+// queryDevicePage returns the ids and the timescale table patterns of a page of the devices matching the condition.
+// Devices are sorted by id, so that the last id can be used as after for the next page.
+// Returns nil ids, if permission-search does not return a result.
+func (c *Controller) queryDevicePage(token string, condition permissions.ConditionConfig, limit int, after *permissions.ListAfter) (deviceIds []string, tables []string, err error) {
+	deviceList, err := c.queryDevices(token, &permissions.Selection{Condition: condition}, limit, after)
+	if err != nil || deviceList == nil {
+		return nil, nil, err
+	}
+	deviceIds = []string{}
+	tables = []string{}
+	for _, deviceMap := range deviceList {
+		deviceId, ok := deviceMap["id"].(string)
+		if !ok {
+			return nil, nil, errUnexpectedReponseFormat
+		}
+		deviceIds = append(deviceIds, deviceId)
+		shortDeviceId, err := models.ShortenId(deviceId)
 		if err != nil {
-			return result, err
+			return nil, nil, err
 		}
-		c.logDebug("DevicesTree: Requests " + time.Since(timer2).String())
+		tables = append(tables, "device:"+shortDeviceId+".*")
 	}
-	c.logDebug("DevicesTree " + time.Since(timer).String())
-	return
+	return deviceIds, tables, nil
+}
+
+// queryDevices returns a page of devices, which match the filter. Returns nil, if the permission search has no result.
+func (c *Controller) queryDevices(token string, filter *permissions.Selection, limit int, after *permissions.ListAfter) (devices []map[string]interface{}, err error) {
+	query := permissions.QueryMessage{
+		Resource: "devices",
+		Find: &permissions.QueryFind{
+			QueryListCommons: permissions.QueryListCommons{
+				Offset:   0,
+				Limit:    limit,
+				After:    after,
+				SortBy:   "id",
+				SortDesc: true,
+			},
+			Filter: filter,
+		}}
+	res, code, err := c.permClient.Query(token, query)
+	if err != nil {
+		return nil, err
+	}
+	if code != http.StatusOK {
+		return nil, errors.New("unexpected upstream status code")
+	}
+	if res == nil {
+		return nil, nil
+	}
+	deviceList, ok := res.([]interface{})
+	if !ok {
+		return nil, errUnexpectedReponseFormat
+	}
+	devices = []map[string]interface{}{}
+	for _, device := range deviceList {
+		deviceMap, ok := device.(map[string]interface{})
+		if !ok {
+			return nil, errUnexpectedReponseFormat
+		}
+		devices = append(devices, deviceMap)
+	}
+	return devices, nil
 }
@@ -0,0 +1,186 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+// GetEstimationAccuracy returns the accuracy of the recorded estimations of the month, formatted as 2006-01,
+// or of all months, if month is empty
+func (c *Controller) GetEstimationAccuracy(month string) ([]model.EstimationAccuracy, error) {
+	if month != "" {
+		_, _, err := c.billing.Period(month)
+		if err != nil {
+			return nil, err
+		}
+	}
+	records, err := c.db.ListEstimationRecords(month)
+	if err != nil {
+		return nil, err
+	}
+	return model.GetEstimationAccuracies(records), nil
+}
+
+// runEstimationRecords records the current estimations immediately and then every interval.
+// Closed months are evaluated after each recording.
+func (c *Controller) runEstimationRecords(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := c.recordEstimations()
+		if err != nil {
+			log.Println("ERROR: unable to record estimations, retry in", interval, err)
+		}
+		err = c.evaluateEstimations()
+		if err != nil {
+			log.Println("ERROR: unable to evaluate estimations, retry in", interval, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordEstimations stores the month end estimation of each cost type of all users. Users, whose estimation fails,
+// are logged and skipped.
+func (c *Controller) recordEstimations() error {
+	now := time.Now().UTC()
+	start := c.billing.PeriodStart(now)
+	end := start.AddDate(0, 1, 0)
+	if c.tokenProvider == nil {
+		return fmt.Errorf("missing auth_endpoint config")
+	}
+	token, err := c.tokenProvider()
+	if err != nil {
+		return err
+	}
+	users, err := c.getUsers(token, start, now)
+	if err != nil {
+		return err
+	}
+	for userId := range users {
+		tree, err := c.getCostTreeWithTiers(userId, token, true, false, model.EstimationOptions{}, nil, nil)
+		if err != nil {
+			log.Println("ERROR: unable to record estimations of", userId, err)
+			continue
+		}
+		for costType, node := range tree {
+			err = c.db.SetEstimationRecord(model.EstimationRecord{
+				UserId:     userId,
+				Month:      c.billing.PeriodName(start),
+				CostType:   costType,
+				Strategy:   c.estimationStrategyName(costType, ""),
+				Timestamp:  now,
+				Progress:   float64(now.Sub(start)) / float64(end.Sub(start)),
+				Estimation: node.EstimationMonth.Total().InexactFloat64(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// evaluateEstimations adds the actual cost to the records of all months before the current month, which have not
+// been evaluated yet. Snapshots are used as actual cost, if available. Users, whose actual cost fails, are logged
+// and their month is evaluated again in the next run.
+func (c *Controller) evaluateEstimations() error {
+	current := c.billing.PeriodName(time.Now())
+	months, err := c.db.ListEstimationRecordMonths()
+	if err != nil {
+		return err
+	}
+	var token string
+	for _, month := range months {
+		if month >= current {
+			continue
+		}
+		evaluated, err := c.db.AreEstimationsEvaluated(month)
+		if err != nil {
+			return err
+		}
+		if evaluated {
+			continue
+		}
+		if token == "" {
+			if c.tokenProvider == nil {
+				return fmt.Errorf("missing auth_endpoint config")
+			}
+			token, err = c.tokenProvider()
+			if err != nil {
+				return err
+			}
+		}
+		start, end, err := c.billing.Period(month)
+		if err != nil {
+			return err
+		}
+		records, err := c.db.ListEstimationRecords(month)
+		if err != nil {
+			return err
+		}
+		actual := map[string]model.CostTree{}
+		failed := map[string]bool{}
+		for _, record := range records {
+			if record.Actual != nil || failed[record.UserId] {
+				continue
+			}
+			tree, ok := actual[record.UserId]
+			if !ok {
+				tree, err = c.getActualCost(month, record.UserId, token, start, end)
+				if err != nil {
+					log.Println("ERROR: unable to evaluate estimations of", record.UserId, "in", month, err)
+					failed[record.UserId] = true
+					continue
+				}
+				actual[record.UserId] = tree
+			}
+			value := tree[record.CostType].Month.Total().InexactFloat64()
+			record.Actual = &value
+			err = c.db.SetEstimationRecord(record)
+			if err != nil {
+				return err
+			}
+		}
+		if len(failed) > 0 {
+			continue
+		}
+		err = c.db.SetEstimationsEvaluated(month)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getActualCost returns the snapshot of the user in the month or calculates the cost tree, if there is none
+func (c *Controller) getActualCost(month string, userId string, token string, start time.Time, end time.Time) (model.CostTree, error) {
+	tree, err := c.getSnapshot(month, userId)
+	if err != nil || tree != nil {
+		return tree, err
+	}
+	return c.getCostTreeWithTiers(userId, token, true, true, model.EstimationOptions{}, &start, &end)
+}
@@ -0,0 +1,196 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+// estimationStrategy predicts the usage of a series from now until the end of the estimation period
+type estimationStrategy interface {
+	// query returns a PromQL expression of the predicted average value of series between now and end.
+	// The expression is evaluated at now.
+	query(series string, now time.Time, end time.Time) string
+	// deviation returns a PromQL expression of the standard deviation of series, which is used to derive the
+	// bounds of the prediction. The expression is evaluated at now.
+	deviation(series string) string
+}
+
+// estimationBoundFactor is the number of standard deviations between the estimation and its 10th and 90th percentile
+const estimationBoundFactor = 1.2816
+
+// estimationBounds returns the lower and upper bound of the predicted average value with the standard deviation.
+// Usage can not become negative, so the lower bound is at least zero.
+func estimationBounds(value float64, deviation float64) (lower float64, upper float64) {
+	return math.Max(0, value-deviation*estimationBoundFactor), value + deviation*estimationBoundFactor
+}
+
+var estimationStrategies = map[model.EstimationStrategy]estimationStrategy{
+	model.EstimationStrategyLast24h: averageStrategy{window: 24 * time.Hour},
+	model.EstimationStrategyLast7d:  averageStrategy{window: 7 * 24 * time.Hour},
+	model.EstimationStrategyLinear:  linearStrategy{window: 7 * 24 * time.Hour},
+	model.EstimationStrategyWeekday: weekdayStrategy{},
+}
+
+// projection is the estimation of the usage between start and end with strategy
+type projection struct {
+	strategy estimationStrategy
+	start    time.Time // end of the measured costs, predictions only use data before
+	end      time.Time // horizon of the estimation
+}
+
+// hours returns the duration of the projection in hours
+func (p *projection) hours() float64 {
+	return p.end.Sub(p.start).Hours()
+}
+
+// newProjection returns the projection of the cost type, which starts at end. The horizon defaults to the end of the
+// billing period of the last measured instant before end. If end is a period end, the projection is empty.
+func (c *Controller) newProjection(costType model.CostType, options model.EstimationOptions, end time.Time) (*projection, error) {
+	strategy, err := c.estimationStrategy(costType, options.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	horizon := c.billing.PeriodEnd(end.Add(-time.Nanosecond))
+	if options.Horizon != nil {
+		horizon = *options.Horizon
+	}
+	if horizon.Before(end) {
+		return nil, fmt.Errorf("estimation horizon %v is before the end of the measured costs %v", horizon.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	return &projection{strategy: strategy, start: end, end: horizon}, nil
+}
+
+// checkStartEnd validates the time range of a cost tree and applies the defaults. Without estimation, start and end
+// have to be provided together. With estimation, end defaults to now and may only be provided with start.
+func (c *Controller) checkStartEnd(skipEstimation bool, start *time.Time, end *time.Time) (*time.Time, *time.Time, error) {
+	if start == nil && end != nil {
+		return nil, nil, fmt.Errorf("must not provide end without start")
+	}
+	if start != nil && end == nil {
+		if skipEstimation {
+			return nil, nil, fmt.Errorf("must not provide only start without estimation")
+		}
+		now := time.Now()
+		end = &now
+	}
+	if start == nil {
+		start, end = c.defaultStartEnd()
+	}
+	if end.Before(*start) {
+		return nil, nil, fmt.Errorf("end must not be before start")
+	}
+	return start, end, nil
+}
+
+// estimationStrategy returns the requested strategy or, if empty, the configured strategy of the cost type
+func (c *Controller) estimationStrategy(costType model.CostType, requested model.EstimationStrategy) (estimationStrategy, error) {
+	name := c.estimationStrategyName(costType, requested)
+	strategy, ok := estimationStrategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown estimation strategy %v", name)
+	}
+	return strategy, nil
+}
+
+// estimationStrategyName returns the name of the strategy, which is used by estimationStrategy
+func (c *Controller) estimationStrategyName(costType model.CostType, requested model.EstimationStrategy) model.EstimationStrategy {
+	if requested != "" {
+		return requested
+	}
+	if name := c.config.EstimationStrategies[costType]; name != "" {
+		return name
+	}
+	return model.EstimationStrategyLast24h
+}
+
+// averageStrategy assumes that the average of the last window continues
+type averageStrategy struct {
+	window time.Duration
+}
+
+func (s averageStrategy) query(series string, now time.Time, end time.Time) string {
+	return "avg_over_time(" + series + "[" + s.window.String() + ":])"
+}
+
+func (s averageStrategy) deviation(series string) string {
+	return "stddev_over_time(" + series + "[" + s.window.String() + ":])"
+}
+
+// linearStrategy extrapolates the trend of the last window. The average of a linear function between now and end
+// is its value at the midpoint.
+type linearStrategy struct {
+	window time.Duration
+}
+
+func (s linearStrategy) query(series string, now time.Time, end time.Time) string {
+	midpoint := end.Sub(now).Seconds() / 2
+	if midpoint < 0 {
+		midpoint = 0
+	}
+	return "clamp_min(predict_linear(" + series + "[" + s.window.String() + ":], " + strconv.FormatFloat(midpoint, 'f', 0, 64) + "), 0)"
+}
+
+// deviation uses the deviation around the mean, which is an upper limit of the deviation around the trend
+func (s linearStrategy) deviation(series string) string {
+	return "stddev_over_time(" + series + "[" + s.window.String() + ":])"
+}
+
+// weekdayStrategy assumes that each remaining day has the usage of the same weekday one week ago
+type weekdayStrategy struct{}
+
+func (s weekdayStrategy) query(series string, now time.Time, end time.Time) string {
+	total := end.Sub(now).Hours()
+	if total <= 0 {
+		return averageStrategy{window: 24 * time.Hour}.query(series, now, end)
+	}
+	// weights[i] is the share of the remaining hours, which are predicted by the day ending i days ago
+	weights := [7]float64{}
+	for k := 0; now.Add(time.Duration(k) * 24 * time.Hour).Before(end); k++ {
+		dayStart := now.Add(time.Duration(k) * 24 * time.Hour)
+		dayEnd := dayStart.Add(24 * time.Hour)
+		if dayEnd.After(end) {
+			dayEnd = end
+		}
+		weights[6-k%7] += dayEnd.Sub(dayStart).Hours() / total
+	}
+	// series without values on a day count as zero, as long as they have values in the last week
+	fallback := "avg_over_time(" + series + "[" + (7 * 24 * time.Hour).String() + ":]) * 0"
+	parts := []string{}
+	for i, weight := range weights {
+		if weight == 0 {
+			continue
+		}
+		day := "avg_over_time(" + series + "[" + (24 * time.Hour).String() + ":]"
+		if i > 0 {
+			day += " offset " + (time.Duration(i) * 24 * time.Hour).String()
+		}
+		day += ")"
+		parts = append(parts, strconv.FormatFloat(weight, 'f', -1, 64)+" * ("+day+" or "+fallback+")")
+	}
+	return "(" + strings.Join(parts, " + ") + ")"
+}
+
+func (s weekdayStrategy) deviation(series string) string {
+	return "stddev_over_time(" + series + "[" + (7 * 24 * time.Hour).String() + ":])"
+}
@@ -0,0 +1,86 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func TestEstimationStrategyQueries(t *testing.T) {
+	now := time.Date(2024, 3, 29, 12, 0, 0, 0, time.UTC)
+	end := now.Add(60 * time.Hour)
+
+	if q := (averageStrategy{window: 24 * time.Hour}).query("s", now, end); q != "avg_over_time(s[24h0m0s:])" {
+		t.Error(q)
+	}
+	if q := (linearStrategy{window: 24 * time.Hour}).query("s", now, end); q != "clamp_min(predict_linear(s[24h0m0s:], 108000), 0)" {
+		t.Error(q)
+	}
+	expected := "(0.2 * (avg_over_time(s[24h0m0s:] offset 96h0m0s) or avg_over_time(s[168h0m0s:]) * 0)" +
+		" + 0.4 * (avg_over_time(s[24h0m0s:] offset 120h0m0s) or avg_over_time(s[168h0m0s:]) * 0)" +
+		" + 0.4 * (avg_over_time(s[24h0m0s:] offset 144h0m0s) or avg_over_time(s[168h0m0s:]) * 0))"
+	if q := (weekdayStrategy{}).query("s", now, end); q != expected {
+		t.Error(q)
+	}
+}
+
+func TestProjection(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * 24 * time.Hour)
+	p := projection{strategy: averageStrategy{window: 24 * time.Hour}, start: end, end: start.Add(30 * 24 * time.Hour)}
+	if h := p.hours(); h != 480 {
+		t.Error(h)
+	}
+
+	c := &Controller{config: &configuration.ConfigStruct{}}
+	proj, err := c.newProjection(model.CostTypeAnalytics, model.EstimationOptions{}, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proj.end.Equal(start.AddDate(0, 1, 0)) {
+		t.Errorf("expected the end of the period as horizon, got %v", proj.end)
+	}
+	// a range ending at a period start, like last_month, is not projected into the next period
+	proj, err = c.newProjection(model.CostTypeAnalytics, model.EstimationOptions{}, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := proj.hours(); h != 0 {
+		t.Errorf("expected empty projection at the period end, got %v hours", h)
+	}
+
+	if _, _, err := c.checkStartEnd(true, &start, nil); err == nil {
+		t.Error("expected error for start without end and estimation")
+	}
+	if _, _, err := c.checkStartEnd(false, nil, &end); err == nil {
+		t.Error("expected error for end without start")
+	}
+	if _, _, err := c.checkStartEnd(true, &end, &start); err == nil {
+		t.Error("expected error for end before start")
+	}
+	s, e, err := c.checkStartEnd(false, &start, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Equal(start) || e.Before(start) {
+		t.Error(s, e)
+	}
+}
@@ -21,7 +21,6 @@ import (
 	"fmt"
 	"log"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -37,68 +36,32 @@ import (
 
 var exportTableMatch = regexp.MustCompile("userid:(.{22})_export:(.{22}).*")
 
-func (c *Controller) GetExportsTree(userId string, token string, admin bool, skipEstimation bool, start *time.Time, end *time.Time) (result model.CostWithChildren, err error) {
+func (c *Controller) GetExportsTree(userId string, token string, admin bool, skipEstimation bool, estimation model.EstimationOptions, start *time.Time, end *time.Time) (result model.CostWithChildren, err error) {
 	timer := time.Now()
 
-	if (start == nil && end != nil) || (start != nil && end == nil) || (start != nil && !skipEstimation) {
-		return result, fmt.Errorf("must not provide only one of start or end. must not provide start and stop without skipEstimation")
+	start, end, err = c.checkStartEnd(skipEstimation, start, end)
+	if err != nil {
+		return result, err
 	}
-	if start == nil {
-		start, end = defaultStartEnd()
+	var proj *projection
+	if !skipEstimation {
+		proj, err = c.newProjection(model.CostTypeExports, estimation, *end)
+		if err != nil {
+			return result, err
+		}
 	}
 	result = model.CostWithChildren{
 		CostWithEstimation: model.CostWithEstimation{},
 		Children:           map[string]model.CostWithChildren{},
 	}
+	pricingModels := c.getPricingModels()
 
-	var instances serving.Instances
-
-	t := true
-	options := serving.ListOptions{
-		InternalOnly: &t,
-	}
-	if !admin {
-		resp, err := c.servingClient.ListInstances(token, &options)
-		if err != nil {
-			return result, err
-		}
-		instances = resp.Instances
-	} else {
-		instances, err = c.servingClient.ListInstancesAsAdmin(token, &options)
-		if err != nil {
-			return
-		}
-	}
-
-	shortUserId, err := models.ShortenId(userId)
+	tables, err := c.exportTables(userId, token, admin)
 	if err != nil {
 		return result, err
 	}
 
-	tables := []string{}
-
-	for _, instance := range instances {
-		if instance.UserId != userId || instance.ExportDatabase.Url != c.config.ServingTimescaleConfiguredUrl {
-			continue
-		}
-		id := instance.ID.String()
-
-		shortId, err := models.ShortenId(id)
-		if err != nil {
-			return result, err
-		}
-
-		tables = append(tables, "userid:"+shortUserId+"_export:"+shortId)
-	}
-
-	durationPassed := end.Sub(*start).Round(time.Second)
-	now := time.Now() // This is fine as getting a prediction and providing start and end times is not allowed
-	endOfMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC)
-	durationRemaining := endOfMonth.Sub(now)
-
-	tableSizeByteMap := map[string]float64{}
-
-	insertWithQuery := func(promQuery string, estimation bool, ts time.Time) error {
+	insertWithQuery := func(promQuery string, ts time.Time, callback func(labels prometheus_model.Metric, value float64, child *model.CostWithChildren)) error {
 		resp, w, err := c.prometheus.Query(context.Background(), promQuery, ts)
 		if err != nil {
 			return err
@@ -130,7 +93,6 @@ func (c *Controller) GetExportsTree(userId string, token string, admin bool, ski
 				return err
 			}
 
-			tableSizeBytes := sampleToFloat(element.Value)
 			child, ok := result.Children[string(exportId)]
 			if !ok {
 				child = model.CostWithChildren{
@@ -140,37 +102,53 @@ func (c *Controller) GetExportsTree(userId string, token string, admin bool, ski
 					},
 				}
 			}
-			if estimation {
-				tableSizeBytesEstimation := tableSizeBytes
-				tableSizeBytes, ok := tableSizeByteMap[exportId]
-				if !ok {
-					tableSizeBytes = 0
-				}
-
-				avgFutureTableSize := (tableSizeBytesEstimation + tableSizeBytes) / 2
-				futureCost := c.pricingModel.Storage * avgFutureTableSize * durationRemaining.Hours() / 1000000000 // cost * avg-size * hours-progressed / correction-bytes-in-gb
-				child.CostWithEstimation.EstimationMonth.Storage = child.CostWithEstimation.Month.Storage + futureCost
-				result.CostWithEstimation.EstimationMonth.Storage += child.EstimationMonth.Storage
-			} else {
-				tableSizeByteMap[exportId] = tableSizeBytes
-				child.CostWithEstimation.Month.Storage = c.pricingModel.Storage * tableSizeBytes * durationPassed.Hours() / 1000000000 // cost * avg-size * hours-progressed / correction-bytes-in-gb
-				result.CostWithEstimation.Month.Storage += child.Month.Storage
-			}
+			callback(element.Metric, sampleToFloat(element.Value), &child)
 			result.Children[exportId] = child
 		}
 		return nil
 	}
-	// Costs in current month
-	promQuery := "avg_over_time(avg by (table) (timescale_table_size_bytes{table=~\"" + strings.Join(tables, "|") + "\"})[" + durationPassed.String() + ":])"
-	err = insertWithQuery(promQuery, false, *end)
-	if err != nil {
-		return result, err
+	series := "avg by (table) (timescale_table_size_bytes{table=~\"" + strings.Join(tables, "|") + "\"})"
+
+	// Costs in current month, split at pricing model boundaries
+	for _, segment := range pricingModels.Segments(*start, *end) {
+		durationPassed := segment.End.Sub(segment.Start).Round(time.Second)
+		priceHours := segment.StoragePrice("", model.CostTypeExports, "") * durationPassed.Hours()
+		err = insertWithQuery("avg_over_time("+series+"["+durationPassed.String()+":])", segment.End, func(_ prometheus_model.Metric, tableSizeBytes float64, child *model.CostWithChildren) {
+			month := model.CostEntry{
+				Storage:        model.ToDecimal(priceHours * tableSizeBytes / 1000000000), // cost * hours-progressed * avg-size / correction-bytes-in-gb
+				StorageGbHours: tableSizeBytes * durationPassed.Hours() / 1000000000,
+			}
+			child.Month.Add(month)
+			result.Month.Add(month)
+			if !skipEstimation {
+				addEstimation(&child.CostWithEstimation, month, month, month)
+				addEstimation(&result.CostWithEstimation, month, month, month)
+			}
+		})
+		if err != nil {
+			return result, err
+		}
 	}
 
 	// Estimations
 	if !skipEstimation {
-		promQuery = "predict_linear(avg by (table) (timescale_table_size_bytes{table=~\"" + strings.Join(tables, "|") + "\"})[24h:], " + strconv.FormatFloat(durationRemaining.Seconds(), 'f', 0, 64) + ")"
-		err = insertWithQuery(promQuery, true, time.Now())
+		deviations, err := c.queryVectorIndex(proj.strategy.deviation(series), proj.start)
+		if err != nil {
+			return result, err
+		}
+		hoursRemaining := proj.hours()
+		priceHoursRemaining := pricingModels.PriceHours(proj.start, proj.end, timescaleStoragePrice(model.CostTypeExports))
+		future := func(tableSizeBytes float64) model.CostEntry {
+			return model.CostEntry{
+				Storage:        model.ToDecimal(priceHoursRemaining * tableSizeBytes / 1000000000), // cost * hours-remaining * avg-size / correction-bytes-in-gb
+				StorageGbHours: tableSizeBytes * hoursRemaining / 1000000000,
+			}
+		}
+		err = insertWithQuery(proj.strategy.query(series, proj.start, proj.end), proj.start, func(labels prometheus_model.Metric, tableSizeBytes float64, child *model.CostWithChildren) {
+			lower, upper := estimationBounds(tableSizeBytes, float64(deviations[labels.Fingerprint()]))
+			addEstimation(&child.CostWithEstimation, future(lower), future(tableSizeBytes), future(upper))
+			addEstimation(&result.CostWithEstimation, future(lower), future(tableSizeBytes), future(upper))
+		})
 		if err != nil {
 			return result, err
 		}
@@ -178,3 +156,49 @@ func (c *Controller) GetExportsTree(userId string, token string, admin bool, ski
 	c.logDebug("ExportsTree " + time.Since(timer).String())
 	return
 }
+
+// exportTables returns the timescale table names of the exports of the user
+func (c *Controller) exportTables(userId string, token string, admin bool) ([]string, error) {
+	var instances serving.Instances
+
+	t := true
+	options := serving.ListOptions{
+		InternalOnly: &t,
+	}
+	if !admin {
+		resp, err := c.servingClient.ListInstances(token, &options)
+		if err != nil {
+			return nil, err
+		}
+		instances = resp.Instances
+	} else {
+		var err error
+		instances, err = c.servingClient.ListInstancesAsAdmin(token, &options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	shortUserId, err := models.ShortenId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := []string{}
+
+	for _, instance := range instances {
+		if instance.UserId != userId || instance.ExportDatabase.Url != c.config.ServingTimescaleConfiguredUrl {
+			continue
+		}
+		id := instance.ID.String()
+
+		shortId, err := models.ShortenId(id)
+		if err != nil {
+			return nil, err
+		}
+
+		tables = append(tables, "userid:"+shortUserId+"_export:"+shortId)
+	}
+
+	return tables, nil
+}
@@ -55,46 +55,28 @@ func (c *Controller) GetFlowEstimations(authorization string, userid string, flo
 	}
 	c.flowCacheMux.Unlock()
 
+	proj, err := c.newProjection(model.CostTypeAnalytics, model.EstimationOptions{}, time.Now())
+	if err != nil {
+		return nil, err
+	}
 	stats, err := c.getStats(&statsFilter{
-		CPU:     true,
-		RAM:     true,
-		Storage: true,
+		CPU:      true,
+		RAM:      true,
+		Storage:  true,
+		Network:  true,
+		CostType: model.CostTypeAnalytics,
 		filter: filter{
 			Namespace: &c.config.NamespaceAnalytics,
 		},
-		PredictionBasedOn: &d24h,
+		Estimation: proj,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	operatorStats := map[string][]float64{}
-	flowStats := map[string][]float64{}
-	for _, stat := range stats {
-		containerName, ok := stat.Labels["container"]
-		if !ok {
-			flowId, ok := stat.Labels["label_flow_id"]
-			if !ok {
-				return nil, fmt.Errorf("stat is missing container and label_flow_id labels")
-			}
-			l, ok := operatorStats[string(flowId)]
-			if !ok {
-				l = []float64{}
-			}
-			l = append(l, stat.EstimationMonth.Cpu+stat.EstimationMonth.Ram+stat.EstimationMonth.Storage)
-			flowStats[string(flowId)] = l
-		} else {
-			nameParts := strings.Split(string(containerName), "--")
-			if len(nameParts) != 2 {
-				return nil, fmt.Errorf("containerName is not formatted correctly %#v", containerName)
-			}
-			l, ok := operatorStats[nameParts[1]]
-			if !ok {
-				l = []float64{}
-			}
-			l = append(l, stat.EstimationMonth.Cpu+stat.EstimationMonth.Ram+stat.EstimationMonth.Storage)
-			operatorStats[nameParts[1]] = l
-		}
+	operatorStats, flowStats, err := flowEstimationStats(stats)
+	if err != nil {
+		return nil, err
 	}
 
 	operatorEstimations := map[string]model.Estimation{}
@@ -141,3 +123,72 @@ func (c *Controller) GetFlowEstimations(authorization string, userid string, flo
 	}
 	return estimations, nil
 }
+
+// flowEstimationStats returns the estimated costs of all operator containers and of all pod level stats with a flow id.
+// Pod level stats without flow id, e.g. network traffic of operator pods, are attributed to the containers of the pod.
+func flowEstimationStats(stats []stat) (operatorStats map[string][]float64, flowStats map[string][]float64, err error) {
+	operatorStats = map[string][]float64{}
+	flowStats = map[string][]float64{}
+	containers, pods := attributePodStats(stats, func(s stat) bool {
+		_, ok := s.Labels["label_flow_id"]
+		return ok
+	})
+	for _, container := range containers {
+		containerName := container.Labels["container"]
+		nameParts := strings.Split(string(containerName), "--")
+		if len(nameParts) != 2 {
+			return nil, nil, fmt.Errorf("containerName is not formatted correctly %#v", containerName)
+		}
+		operatorStats[nameParts[1]] = append(operatorStats[nameParts[1]], container.cost)
+	}
+	for _, pod := range pods {
+		flowId, ok := pod.Labels["label_flow_id"]
+		if !ok {
+			continue // pod without containers and flow id, can not be attributed to an operator or flow
+		}
+		flowStats[string(flowId)] = append(flowStats[string(flowId)], pod.EstimationMonth.Total().InexactFloat64())
+	}
+	return operatorStats, flowStats, nil
+}
+
+type attributedStat struct {
+	stat
+	cost float64 // estimated cost of the container including its share of the pod level stats
+}
+
+// attributePodStats splits the estimated cost of pod level stats, which are not available per container (storage and
+// network), evenly between the container stats of the same pod. Pod level stats, which are kept by keep or which
+// belong to pods without container stats, are returned unchanged. keep may be nil.
+func attributePodStats(stats []stat, keep func(s stat) bool) (containers []attributedStat, pods []stat) {
+	podKey := func(s stat) string {
+		return string(s.Labels["namespace"]) + "/" + string(s.Labels["pod"])
+	}
+	containerCount := map[string]int{}
+	for _, s := range stats {
+		if _, ok := s.Labels["container"]; ok {
+			containerCount[podKey(s)]++
+		}
+	}
+	podCost := map[string]float64{}
+	for _, s := range stats {
+		if _, ok := s.Labels["container"]; ok {
+			continue
+		}
+		if (keep != nil && keep(s)) || containerCount[podKey(s)] == 0 {
+			pods = append(pods, s)
+			continue
+		}
+		podCost[podKey(s)] += s.EstimationMonth.Total().InexactFloat64()
+	}
+	for _, s := range stats {
+		if _, ok := s.Labels["container"]; !ok {
+			continue
+		}
+		key := podKey(s)
+		containers = append(containers, attributedStat{
+			stat: s,
+			cost: s.EstimationMonth.Total().InexactFloat64() + podCost[key]/float64(containerCount[key]),
+		})
+	}
+	return containers, pods
+}
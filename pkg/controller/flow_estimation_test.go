@@ -0,0 +1,50 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	prometheus_model "github.com/prometheus/common/model"
+)
+
+func TestFlowEstimationStats(t *testing.T) {
+	estimation := func(cost float64) model.CostWithEstimation {
+		return model.CostWithEstimation{EstimationMonth: model.CostEntry{Cpu: model.ToDecimal(cost)}}
+	}
+	stats := []stat{
+		{Labels: prometheus_model.Metric{"namespace": "ns", "pod": "p1", "container": "pipeline--op1"}, CostWithEstimation: estimation(1)},
+		{Labels: prometheus_model.Metric{"namespace": "ns", "pod": "p1", "container": "pipeline--op2"}, CostWithEstimation: estimation(3)},
+		// network traffic of the pod, which has no container label
+		{Labels: prometheus_model.Metric{"namespace": "ns", "pod": "p1"}, CostWithEstimation: model.CostWithEstimation{EstimationMonth: model.CostEntry{Network: model.ToDecimal(2), NetworkGb: 20}}},
+		// storage of a pod, which belongs to a flow
+		{Labels: prometheus_model.Metric{"namespace": "ns", "pod": "p2", "label_flow_id": "flow"}, CostWithEstimation: estimation(5)},
+		// network traffic of a pod without containers and flow id
+		{Labels: prometheus_model.Metric{"namespace": "ns", "pod": "p3"}, CostWithEstimation: estimation(7)},
+	}
+	operatorStats, flowStats, err := flowEstimationStats(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(operatorStats) != 2 || len(operatorStats["op1"]) != 1 || operatorStats["op1"][0] != 2 || operatorStats["op2"][0] != 4 {
+		t.Errorf("expected network traffic split between the operators of the pod, got %#v", operatorStats)
+	}
+	if len(flowStats) != 1 || len(flowStats["flow"]) != 1 || flowStats["flow"][0] != 5 {
+		t.Errorf("unexpected flow stats %#v", flowStats)
+	}
+}
@@ -18,30 +18,39 @@ package controller
 
 import (
 	"strings"
+	"time"
 
 	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
 )
 
 func (c *Controller) GetImportEstimation(authorization string, userid string, importTypeId string) (estimation *model.Estimation, err error) {
+	proj, err := c.newProjection(model.CostTypeImports, model.EstimationOptions{}, time.Now())
+	if err != nil {
+		return nil, err
+	}
 	stats, err := c.getStats(&statsFilter{
-		CPU:     true,
-		RAM:     true,
-		Storage: false,
+		CPU:      true,
+		RAM:      true,
+		Storage:  false,
+		Network:  true,
+		CostType: model.CostTypeImports,
 		filter: filter{
 			Namespace: &c.config.NamespaceImports,
 			Labels: map[string][]string{
 				"label_import_type_id": {strings.ReplaceAll(importTypeId, ":", "_")},
 			},
 		},
-		PredictionBasedOn: &d24h,
+		Estimation: proj,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	// network stats are only available per pod, so they are split between the containers of the pod
+	containers, _ := attributePodStats(stats, nil)
 	l := []float64{}
-	for _, stat := range stats {
-		l = append(l, stat.EstimationMonth.Cpu+stat.EstimationMonth.Ram+stat.EstimationMonth.Storage)
+	for _, container := range containers {
+		l = append(l, container.cost)
 	}
 	min, max, mean, median := calcMinMaxMeanMedian(l)
 	return &model.Estimation{Min: min, Max: max, Mean: mean, Median: median}, nil
@@ -17,21 +17,31 @@
 package controller
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
 )
 
-func (c *Controller) GetImportsTree(userId string, skipEstimation bool, start *time.Time, end *time.Time) (tree model.CostWithChildren, err error) {
+func (c *Controller) GetImportsTree(userId string, skipEstimation bool, estimation model.EstimationOptions, start *time.Time, end *time.Time) (tree model.CostWithChildren, err error) {
 	timer := time.Now()
-	if (start == nil && end != nil) || (start != nil && end == nil) || (start != nil && !skipEstimation) {
-		return tree, fmt.Errorf("must not provide only one of start or end. must not provide start and stop without skipEstimation")
+	start, end, err = c.checkStartEnd(skipEstimation, start, end)
+	if err != nil {
+		return tree, err
+	}
+	var proj *projection
+	if !skipEstimation {
+		proj, err = c.newProjection(model.CostTypeImports, estimation, *end)
+		if err != nil {
+			return tree, err
+		}
 	}
 	filter := &statsFilter{
-		CPU:     true,
-		RAM:     true,
-		Storage: false,
+		CPU:        true,
+		RAM:        true,
+		Storage:    false,
+		Network:    true,
+		CostType:   model.CostTypeImports,
+		Estimation: proj,
 		filter: filter{
 			Namespace: &c.config.NamespaceImports,
 			Labels: map[string][]string{
@@ -41,9 +51,6 @@ func (c *Controller) GetImportsTree(userId string, skipEstimation bool, start *t
 			End:   end,
 		},
 	}
-	if !skipEstimation {
-		filter.PredictionBasedOn = &d24h
-	}
 	stats, err := c.getStats(filter)
 	if err != nil {
 		return
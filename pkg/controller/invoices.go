@@ -0,0 +1,120 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/go-pdf/fpdf"
+)
+
+var ErrMonthNotClosed = errors.New("month is not closed yet")
+
+// GetInvoice returns the invoice of the user for a closed month, formatted as 2006-01. If snapshots are enabled,
+// the month is closed after the snapshots of all users have been stored.
+// The invoice is issued on the first request and never changes afterwards. Months without cost are not invoiced.
+func (c *Controller) GetInvoice(userId string, token string, admin bool, month string) (invoice model.Invoice, err error) {
+	start, end, err := c.billing.Period(month)
+	if err != nil {
+		return invoice, err
+	}
+	if end.After(time.Now()) {
+		return invoice, ErrMonthNotClosed
+	}
+	invoice, found, err := c.db.GetInvoice(month, userId)
+	if err != nil || found {
+		return invoice, err
+	}
+	if c.config.SnapshotsEnabled {
+		closed, err := c.db.IsMonthClosed(month)
+		if err != nil {
+			return invoice, err
+		}
+		if !closed {
+			return invoice, ErrMonthNotClosed
+		}
+	}
+	tree, err := c.GetCostTree(userId, token, admin, true, model.EstimationOptions{}, &start, &end)
+	if err != nil {
+		return invoice, err
+	}
+	invoice = model.NewInvoice(userId, month, tree, c.getPricingModels().At(start).Currency, c.invoiceTaxes, c.rounding)
+	if len(invoice.Items) == 0 {
+		return invoice, fmt.Errorf("%w: no cost in month %v", ErrNotFound, month)
+	}
+	invoice.IssuedAt = time.Now().UTC()
+	return c.db.IssueInvoice(invoice)
+}
+
+// WriteInvoicePdf renders the invoice as pdf
+func WriteInvoicePdf(invoice model.Invoice, w io.Writer) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	format := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	amount := func(f float64) string {
+		return tr(format(f) + " " + invoice.Currency)
+	}
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, tr("Invoice "+invoice.Number), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, tr("Month: "+invoice.Month), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, tr("Customer: "+invoice.UserId), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, tr("Issued: "+invoice.IssuedAt.Format(time.DateOnly)), "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	widths := []float64{25, 60, 20, 22, 23, 20, 20}
+	header := []string{"Cost type", "Name", "Item", "Quantity", "Unit", "Unit price", "Amount"}
+	pdf.SetFont("Helvetica", "B", 8)
+	for i, h := range header {
+		pdf.CellFormat(widths[i], 7, h, "B", 0, "L", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 8)
+	for _, item := range invoice.Items {
+		row := []string{item.CostType, item.Name, item.Description, strconv.FormatFloat(item.Quantity, 'f', 2, 64), item.Unit, format(item.UnitPrice), format(item.Amount)}
+		for i, cell := range row {
+			align := "L"
+			if i == 3 || i >= 5 {
+				align = "R"
+			}
+			pdf.CellFormat(widths[i], 6, tr(cell), "", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+
+	total := func(label string, value float64, style string) {
+		pdf.SetFont("Helvetica", style, 10)
+		pdf.CellFormat(150, 7, tr(label), "", 0, "R", false, 0, "")
+		pdf.CellFormat(40, 7, amount(value), "", 1, "R", false, 0, "")
+	}
+	total("Subtotal", invoice.Subtotal, "")
+	for _, tax := range invoice.Taxes {
+		total(tax.Name+" ("+format(tax.Rate*100)+" %)", tax.Amount, "")
+	}
+	total("Total", invoice.Total, "B")
+	return pdf.Output(w)
+}
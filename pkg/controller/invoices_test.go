@@ -0,0 +1,60 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/database"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func TestGetInvoiceOfClosedMonth(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	c := &Controller{db: db, config: &configuration.ConfigStruct{SnapshotsEnabled: true}}
+
+	_, err = c.GetInvoice("user", "", false, c.billing.PeriodName(time.Now()))
+	if !errors.Is(err, ErrMonthNotClosed) {
+		t.Fatalf("expected ErrMonthNotClosed for the current month, got %v", err)
+	}
+
+	// the month has ended, but the snapshots are not stored yet
+	_, err = c.GetInvoice("user", "", false, "2024-01")
+	if !errors.Is(err, ErrMonthNotClosed) {
+		t.Fatalf("expected ErrMonthNotClosed before the month is closed, got %v", err)
+	}
+
+	issued, err := db.IssueInvoice(model.Invoice{UserId: "user", Month: "2024-01", Total: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoice, err := c.GetInvoice("user", "", false, "2024-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invoice.Number != issued.Number || invoice.Total != 1 {
+		t.Fatalf("expected the issued invoice %#v, got %#v", issued, invoice)
+	}
+}
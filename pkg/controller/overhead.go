@@ -0,0 +1,243 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/shopspring/decimal"
+)
+
+// clusterOverhead holds the cluster wide overhead and the cost of all namespaces, which are not overhead namespaces
+type clusterOverhead struct {
+	idle               model.CostEntry          // cost of allocatable node capacity, which is not used by any container
+	platform           model.CostEntry          // cost of the usage of the overhead namespaces
+	idleEstimation     model.CostWithEstimation // idle cost including the projection and its bounds, if requested
+	platformEstimation model.CostWithEstimation // platform cost including the projection and its bounds, if requested
+	attributed         model.CostEntry          // priced cost of all other namespaces
+}
+
+// getClusterOverhead returns the overhead between start and end. If proj is set, the idle capacity and the usage
+// of the overhead namespaces are projected with the estimation strategy.
+func (c *Controller) getClusterOverhead(start time.Time, end time.Time, proj *projection) (result clusterOverhead, err error) {
+	platformSelector := ""
+	if len(c.config.OverheadNamespaces) > 0 {
+		platformSelector = "{namespace=~\"" + strings.Join(c.config.OverheadNamespaces, "|") + "\"}"
+	}
+	resources := []struct {
+		resource string
+		usage    string
+		unit     float64
+		price    func(m model.PricingModel) float64
+		add      func(e *model.CostEntry, cost float64, quantity float64)
+	}{
+		{
+			resource: "cpu",
+			usage:    "namespace_pod_container:container_cpu_usage_seconds_total:avg_rate_1h",
+			unit:     1,
+			price:    func(m model.PricingModel) float64 { return m.CPU },
+			add: func(e *model.CostEntry, cost float64, quantity float64) {
+				e.Cpu = e.Cpu.Add(model.ToDecimal(cost))
+				e.CpuHours += quantity
+			},
+		},
+		{
+			resource: "memory",
+			usage:    "namespace_pod_container:container_memory_working_set_bytes:avg_1h",
+			unit:     1000000000,
+			price:    func(m model.PricingModel) float64 { return m.RAM },
+			add: func(e *model.CostEntry, cost float64, quantity float64) {
+				e.Ram = e.Ram.Add(model.ToDecimal(cost))
+				e.RamGbHours += quantity
+			},
+		},
+	}
+	for _, segment := range c.getPricingModels().Segments(start, end) {
+		durationPassed := segment.End.Sub(segment.Start).Round(time.Second)
+		hours := durationPassed.Hours()
+		for _, r := range resources {
+			capacity, err := c.querySum("sum(avg_over_time(kube_node_status_allocatable{resource=\""+r.resource+"\"}["+durationPassed.String()+"]))", segment.End)
+			if err != nil {
+				return result, err
+			}
+			total, err := c.querySum("avg_over_time(sum("+r.usage+")["+durationPassed.String()+":])", segment.End)
+			if err != nil {
+				return result, err
+			}
+			platform := 0.0
+			if platformSelector != "" {
+				platform, err = c.querySum("avg_over_time(sum("+r.usage+platformSelector+")["+durationPassed.String()+":])", segment.End)
+				if err != nil {
+					return result, err
+				}
+			}
+			price := r.price(segment.PricingModel)
+			idle := math.Max(0, capacity-total) * hours / r.unit
+			platformQuantity := platform * hours / r.unit
+			r.add(&result.idle, idle*price, idle)
+			r.add(&result.platform, platformQuantity*price, platformQuantity)
+		}
+	}
+	addEstimation(&result.idleEstimation, result.idle, result.idle, result.idle)
+	addEstimation(&result.platformEstimation, result.platform, result.platform, result.platform)
+	if proj != nil {
+		hoursRemaining := proj.hours()
+		for _, r := range resources {
+			priceHoursRemaining := c.getPricingModels().PriceHours(proj.start, proj.end, r.price)
+			project := func(series string, node *model.CostWithEstimation) error {
+				value, err := c.querySum(proj.strategy.query(series, proj.start, proj.end), proj.start)
+				if err != nil {
+					return err
+				}
+				deviation, err := c.querySum(proj.strategy.deviation(series), proj.start)
+				if err != nil {
+					return err
+				}
+				lower, upper := estimationBounds(value, deviation)
+				r.add(&node.EstimationMonthLower, lower*priceHoursRemaining/r.unit, lower*hoursRemaining/r.unit)
+				r.add(&node.EstimationMonth, value*priceHoursRemaining/r.unit, value*hoursRemaining/r.unit)
+				r.add(&node.EstimationMonthUpper, upper*priceHoursRemaining/r.unit, upper*hoursRemaining/r.unit)
+				return nil
+			}
+			err = project("clamp_min(sum(kube_node_status_allocatable{resource=\""+r.resource+"\"}) - sum("+r.usage+"), 0)", &result.idleEstimation)
+			if err != nil {
+				return result, err
+			}
+			if platformSelector != "" {
+				err = project("sum("+r.usage+platformSelector+")", &result.platformEstimation)
+				if err != nil {
+					return result, err
+				}
+			}
+		}
+	}
+	result.attributed, err = c.getAttributedCost(start, end)
+	return result, err
+}
+
+// getAttributedCost returns the cost of all pods outside the overhead namespaces, priced like the pods of the users.
+// Cost type overrides are not applied, since the cost type of a pod is only known within the tree of its user.
+func (c *Controller) getAttributedCost(start time.Time, end time.Time) (result model.CostEntry, err error) {
+	stats, err := c.getStats(&statsFilter{
+		filter:  filter{Start: &start, End: &end},
+		CPU:     true,
+		RAM:     true,
+		Storage: true,
+		Network: true,
+	})
+	if err != nil {
+		return result, err
+	}
+	for _, s := range stats {
+		if !slices.Contains(c.config.OverheadNamespaces, string(s.Labels["namespace"])) {
+			result.Add(s.Month)
+		}
+	}
+	return result, nil
+}
+
+// querySum returns the sum of all values of the query result
+func (c *Controller) querySum(query string, ts time.Time) (sum float64, err error) {
+	promResp, w, err := c.prometheus.Query(context.Background(), query, ts)
+	if err != nil {
+		return 0, err
+	}
+	values, err := validateAndGetValuesPromResponse(promResp, w)
+	if err != nil {
+		return 0, err
+	}
+	for _, element := range values {
+		sum += sampleToFloat(element.Value)
+	}
+	return sum, nil
+}
+
+// GetOverheadTree distributes the idle cluster capacity and the usage of the overhead namespaces to the user.
+// The share of the user is the fraction of the priced pod cost of the tree in the cost of all
+// namespaces, which are not overhead namespaces. Costs billed per request are not part of the share.
+func (c *Controller) GetOverheadTree(tree model.CostTree, skipEstimation bool, estimation model.EstimationOptions, start *time.Time, end *time.Time) (result model.CostWithChildren, err error) {
+	timer := time.Now()
+	start, end, err = c.checkStartEnd(skipEstimation, start, end)
+	if err != nil {
+		return result, err
+	}
+	var proj *projection
+	if !skipEstimation {
+		proj, err = c.newProjection(model.CostTypeOverhead, estimation, *end)
+		if err != nil {
+			return result, err
+		}
+	}
+	overhead, err := c.getClusterOverhead(*start, *end, proj)
+	if err != nil {
+		return result, err
+	}
+
+	share := overheadShare(tree, overhead.attributed)
+
+	node := func(cost model.CostEntry, estimation model.CostWithEstimation) model.CostWithChildren {
+		n := model.CostWithChildren{
+			CostWithEstimation: model.CostWithEstimation{
+				Month:           cost.Scale(share),
+				EstimationMonth: model.CostEntry{},
+			},
+		}
+		if !skipEstimation {
+			n.EstimationMonthLower = estimation.EstimationMonthLower.Scale(share)
+			n.EstimationMonth = estimation.EstimationMonth.Scale(share)
+			n.EstimationMonthUpper = estimation.EstimationMonthUpper.Scale(share)
+		}
+		return n
+	}
+
+	result = model.CostWithChildren{
+		CostWithEstimation: model.CostWithEstimation{
+			Month:           model.CostEntry{},
+			EstimationMonth: model.CostEntry{},
+		},
+		Children: map[string]model.CostWithChildren{
+			"idle":     node(overhead.idle, overhead.idleEstimation),
+			"platform": node(overhead.platform, overhead.platformEstimation),
+		},
+	}
+	for _, child := range result.Children {
+		result.Add(child.CostWithEstimation)
+	}
+	c.logDebug("OverheadTree " + time.Since(timer).String())
+	return result, nil
+}
+
+// overheadShare returns the fraction of the priced pod cost of the tree in the attributed cost of the cluster
+func overheadShare(tree model.CostTree, attributed model.CostEntry) float64 {
+	user := decimal.Zero
+	for k, child := range tree {
+		if k == model.CostTypeOverhead || k == model.CostTypeTiers {
+			continue
+		}
+		user = user.Add(child.Month.Total()).Sub(child.Month.RequestsCost)
+	}
+	total := attributed.Total().Sub(attributed.RequestsCost)
+	if !total.IsPositive() {
+		return 0
+	}
+	return math.Min(1, user.Div(total).InexactFloat64())
+}
@@ -0,0 +1,44 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func TestOverheadShare(t *testing.T) {
+	tree := model.CostTree{
+		model.CostTypeAnalytics: {CostWithEstimation: model.CostWithEstimation{Month: model.CostEntry{Cpu: model.ToDecimal(1), Storage: model.ToDecimal(2)}}},
+		// overrides and network are part of the priced cost
+		model.CostTypeImports: {CostWithEstimation: model.CostWithEstimation{Month: model.CostEntry{Ram: model.ToDecimal(3), Network: model.ToDecimal(1)}}},
+		// requests are not billed by pods
+		model.CostTypeApiCalls: {CostWithEstimation: model.CostWithEstimation{Month: model.CostEntry{RequestsCost: model.ToDecimal(10)}}},
+		model.CostTypeTiers:    {CostWithEstimation: model.CostWithEstimation{Month: model.CostEntry{Cpu: model.ToDecimal(-1)}}},
+	}
+	attributed := model.CostEntry{Cpu: model.ToDecimal(10), Ram: model.ToDecimal(10), Storage: model.ToDecimal(10), Network: model.ToDecimal(10)}
+	if share := overheadShare(tree, attributed); share != 0.175 {
+		t.Fatalf("expected share 0.175, got %v", share)
+	}
+	if share := overheadShare(tree, model.CostEntry{Cpu: model.ToDecimal(1)}); share != 1 {
+		t.Fatalf("expected share to be capped at 1, got %v", share)
+	}
+	if share := overheadShare(tree, model.CostEntry{}); share != 0 {
+		t.Fatalf("expected share 0 without attributed cost, got %v", share)
+	}
+}
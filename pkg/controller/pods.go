@@ -20,7 +20,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -33,10 +32,12 @@ import (
 
 type statsFilter struct {
 	filter
-	CPU               bool
-	RAM               bool
-	Storage           bool
-	PredictionBasedOn *time.Duration // can only be set if podFilter.Start == nil && podFilter.End == nil
+	CPU        bool
+	RAM        bool
+	Storage    bool
+	Network    bool
+	Estimation *projection    // nil if no estimation is required, starts at the end of the measured costs
+	CostType   model.CostType // used to select price overrides
 }
 
 type filter struct {
@@ -56,15 +57,18 @@ func checkPodFilterFullyValid(filter *filter) error {
 type stat struct {
 	Labels prometheus_model.Metric
 	model.CostWithEstimation
+	BillingBasis model.BillingMode // applied billing basis of cpu and ram, empty for storage and network
 }
 
 type upsertFlags struct {
 	cpu               bool
 	ram               bool
 	storage           bool
+	network           bool
 	cpuEstimation     bool
 	ramEstimation     bool
 	storageEstimation bool
+	networkEstimation bool
 }
 
 func (c *Controller) getStats(filter *statsFilter) (result []stat, err error) {
@@ -76,7 +80,7 @@ func (c *Controller) getStats(filter *statsFilter) (result []stat, err error) {
 	}
 	// ensure that checkPodFilterFullyValid(podFilter) returns nil now
 	if filter.filter.Start == nil {
-		start, end := defaultStartEnd()
+		start, end := c.defaultStartEnd()
 		filter.filter.Start = start
 		filter.filter.End = end
 	}
@@ -88,7 +92,7 @@ func (c *Controller) getStats(filter *statsFilter) (result []stat, err error) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			cpustats, err := c.getCPUStats(&filter.filter, filter.PredictionBasedOn)
+			cpustats, err := c.getCPUStats(&filter.filter, filter.CostType, filter.Estimation)
 			if err != nil {
 				superErr = err
 			}
@@ -105,7 +109,7 @@ func (c *Controller) getStats(filter *statsFilter) (result []stat, err error) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			ramstats, err := c.getRAMStats(&filter.filter, filter.PredictionBasedOn)
+			ramstats, err := c.getRAMStats(&filter.filter, filter.CostType, filter.Estimation)
 			if err != nil {
 				superErr = err
 			}
@@ -122,7 +126,7 @@ func (c *Controller) getStats(filter *statsFilter) (result []stat, err error) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			storageStats, err := c.getStorageStats(&filter.filter, filter.PredictionBasedOn)
+			storageStats, err := c.getStorageStats(&filter.filter, filter.CostType, filter.Estimation)
 			if err != nil {
 				superErr = err
 			}
@@ -134,6 +138,22 @@ func (c *Controller) getStats(filter *statsFilter) (result []stat, err error) {
 			}
 		}()
 	}
+	if filter.Network {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			networkStats, err := c.getNetworkStats(&filter.filter, filter.CostType, filter.Estimation)
+			if err != nil {
+				superErr = err
+			}
+			mux.Lock()
+			defer mux.Unlock()
+			err = upsertPodStats(networkStats, resultMap, &upsertFlags{network: true, networkEstimation: true})
+			if err != nil {
+				superErr = err
+			}
+		}()
+	}
 	wg.Wait()
 	if superErr != nil {
 		return nil, superErr
@@ -143,158 +163,331 @@ func (c *Controller) getStats(filter *statsFilter) (result []stat, err error) {
 	return
 }
 
-func (c *Controller) getCPUStats(filter *filter, estimationBasedOn *time.Duration) (result []stat, err error) {
+func (c *Controller) getCPUStats(filter *filter, costType model.CostType, estimation *projection) (result []stat, err error) {
 	err = checkPodFilterFullyValid(filter)
 	if err != nil {
 		return nil, err
 	}
-
-	baseQuery0 := "avg_over_time(namespace_pod_container:container_cpu_usage_seconds_total:avg_rate_1h{"
-	if filter.Namespace != nil {
-		baseQuery0 += "namespace=\"" + *filter.Namespace + "\""
+	series, join := c.cpuQueryParts(filter, costType)
+	result, err = c.queryCpuRam(*filter.Start, *filter.End, series, join, costType, estimation, true)
+	if err != nil {
+		return nil, err
 	}
-	baseQuery0 += "}"
+	return result, c.setBillingBasis(result, c.billingMode(costType), cpuUsageSeries(filter), "cpu", filter)
+}
 
-	baseQuery1 := ") * on (namespace, pod) group_left(" + c.config.CustomPrometheusLabels + ") kube_pod_labels{container=\"kube-state-metrics\""
-	if filter.Namespace != nil {
-		baseQuery1 += ", namespace=\"" + *filter.Namespace + "\""
-	}
-	baseQuery1 += getLabelFilterStr(filter.Labels) + "}"
+// cpuQueryParts returns the billed cpu series and its join with the pod labels.
+// The average over a duration is queried with "avg_over_time(" + series + "[1h:])" + join.
+func (c *Controller) cpuQueryParts(filter *filter, costType model.CostType) (series string, join string) {
+	series = billedSeries(c.billingMode(costType), cpuUsageSeries(filter), "cpu", filter.Namespace)
+	return series, c.podLabelsJoin(filter)
+}
 
-	durationPassed := filter.End.Sub(*filter.Start).Round(time.Second)
-	promQuery := baseQuery0 + "[" + durationPassed.String() + ":]" + baseQuery1
-	var promQueryPred *string
-	if estimationBasedOn != nil {
-		s := baseQuery0 + "[" + estimationBasedOn.String() + ":]" + baseQuery1
-		promQueryPred = &s
+func cpuUsageSeries(filter *filter) string {
+	usage := "namespace_pod_container:container_cpu_usage_seconds_total:avg_rate_1h{"
+	if filter.Namespace != nil {
+		usage += "namespace=\"" + *filter.Namespace + "\""
 	}
-	return c.queryCpuRam(durationPassed, *filter.End, &promQuery, promQueryPred, true)
+	return usage + "}"
 }
 
-func (c *Controller) getRAMStats(filter *filter, estimationBasedOn *time.Duration) (result []stat, err error) {
+func (c *Controller) getRAMStats(filter *filter, costType model.CostType, estimation *projection) (result []stat, err error) {
 	err = checkPodFilterFullyValid(filter)
 	if err != nil {
 		return nil, err
 	}
-	baseQuery0 := "avg_over_time(namespace_pod_container:container_memory_working_set_bytes:avg_1h"
+	series, join := c.ramQueryParts(filter, costType)
+	result, err = c.queryCpuRam(*filter.Start, *filter.End, series, join, costType, estimation, false)
+	if err != nil {
+		return nil, err
+	}
+	return result, c.setBillingBasis(result, c.billingMode(costType), ramUsageSeries(filter), "memory", filter)
+}
+
+// ramQueryParts returns the billed ram series and its join with the pod labels.
+// The average over a duration is queried with "avg_over_time(" + series + "[1h:])" + join.
+func (c *Controller) ramQueryParts(filter *filter, costType model.CostType) (series string, join string) {
+	series = billedSeries(c.billingMode(costType), ramUsageSeries(filter), "memory", filter.Namespace)
+	return series, c.podLabelsJoin(filter)
+}
+
+func ramUsageSeries(filter *filter) string {
+	usage := "namespace_pod_container:container_memory_working_set_bytes:avg_1h"
 	if filter.Namespace != nil {
-		baseQuery0 += "{namespace=\"" + *filter.Namespace + "\"}"
+		usage += "{namespace=\"" + *filter.Namespace + "\"}"
 	}
+	return usage
+}
 
-	baseQuery1 := ") * on (namespace, pod) group_left(" + c.config.CustomPrometheusLabels + ") kube_pod_labels{container=\"kube-state-metrics\""
+// podLabelsJoin returns the join of a series with the labels of its pod, which also applies the label filter
+func (c *Controller) podLabelsJoin(filter *filter) string {
+	join := " * on (namespace, pod) group_left(" + c.config.CustomPrometheusLabels + ") kube_pod_labels{container=\"kube-state-metrics\""
 	if filter.Namespace != nil {
-		baseQuery1 += ", namespace=\"" + *filter.Namespace + "\""
+		join += ", namespace=\"" + *filter.Namespace + "\""
 	}
-	baseQuery1 += getLabelFilterStr(filter.Labels) + "}"
+	join += getLabelFilterStr(filter.Labels) + "}"
+	return join
+}
 
-	durationPassed := filter.End.Sub(*filter.Start).Round(time.Second)
-	promQuery := baseQuery0 + "[" + durationPassed.String() + ":]" + baseQuery1
+// billedSeries returns the series of the resource, which is billed in the billing mode.
+// usage is the series of the actual usage of the resource.
+func billedSeries(mode model.BillingMode, usage string, resource string, namespace *string) string {
+	requests := requestsSeries(resource, namespace)
+	switch mode {
+	case model.BillingModeRequests:
+		return "(" + requests + ")"
+	case model.BillingModeMax:
+		// label_replace ensures that 'or' keeps both series, even if they have the same labels
+		return "(max by (namespace, pod, container) (label_replace(" + usage + ", \"basis\", \"usage\", \"\", \"\") or label_replace(" + requests + ", \"basis\", \"requests\", \"\", \"\")))"
+	default:
+		return usage
+	}
+}
 
-	var promQueryPred *string
-	if estimationBasedOn != nil {
-		s := baseQuery0 + "[" + estimationBasedOn.String() + ":]" + baseQuery1
-		promQueryPred = &s
+func requestsSeries(resource string, namespace *string) string {
+	requests := "sum by (namespace, pod, container) (kube_pod_container_resource_requests{resource=\"" + resource + "\""
+	if namespace != nil {
+		requests += ", namespace=\"" + *namespace + "\""
 	}
-	return c.queryCpuRam(durationPassed, *filter.End, &promQuery, promQueryPred, false)
+	return requests + "})"
 }
 
-func (c *Controller) queryCpuRam(durationPassed time.Duration, ts time.Time, promQuery *string, promQueryPred *string, isCpu bool) (result []stat, err error) {
-	if promQuery == nil {
-		return result, fmt.Errorf("promQuery may not be null")
+// setBillingBasis sets the billing basis, which has been applied to the cpu or ram stats between start and end.
+// In max mode, the basis is queried per container: usage, if the usage always exceeded the requests,
+// requests, if it never did, and mixed otherwise.
+func (c *Controller) setBillingBasis(stats []stat, mode model.BillingMode, usage string, resource string, filter *filter) error {
+	if mode != model.BillingModeMax {
+		for i := range stats {
+			stats[i].BillingBasis = mode
+		}
+		return nil
 	}
-	promResp, w, err := c.prometheus.Query(context.Background(), *promQuery, ts)
+	usageBilled := "(max by (namespace, pod, container) (" + usage + ") >bool on (namespace, pod, container) " + requestsSeries(resource, filter.Namespace) + ")"
+	duration := filter.End.Sub(*filter.Start).Round(time.Second)
+	promResp, w, err := c.prometheus.Query(context.Background(), "avg_over_time("+usageBilled+"["+duration.String()+":])", *filter.End)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	values, err := validateAndGetValuesPromResponse(promResp, w)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	fractions := map[string]prometheus_model.SampleValue{}
+	for _, element := range values {
+		fractions[containerKey(element.Metric)] = element.Value
+	}
+	for i := range stats {
+		fraction, ok := fractions[containerKey(stats[i].Labels)]
+		switch {
+		case !ok || fraction == 1:
+			stats[i].BillingBasis = model.BillingModeUsage // containers without requests are billed by usage
+		case fraction == 0:
+			stats[i].BillingBasis = model.BillingModeRequests
+		default:
+			stats[i].BillingBasis = model.BillingBasisMixed
+		}
+	}
+	return nil
+}
+
+// containerKey identifies the container of the labels independent of all other labels
+func containerKey(labels prometheus_model.Metric) string {
+	return string(labels["namespace"]) + "/" + string(labels["pod"]) + "/" + string(labels["container"])
+}
+
+// billingMode returns the configured billing mode of the cost type
+func (c *Controller) billingMode(costType model.CostType) model.BillingMode {
+	mode, ok := c.config.BillingModes[costType]
+	if !ok || mode == "" {
+		return model.BillingModeUsage
+	}
+	return mode
+}
 
-	var estimationValues prometheus_model.Vector
-	var durationRemaining time.Duration
-	if promQueryPred != nil {
-		now := time.Now() // This is fine as getting a prediction and providing start and end times is not allowed
-		endOfMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC)
-		durationRemaining = endOfMonth.Sub(now)
-		promResp, w, err := c.prometheus.Query(context.Background(), *promQueryPred, time.Now()) // predictions are always only relevant from the current point of time
+// queryCpuRam queries the average of series once for every pricing segment between start and end
+// and sums up the costs of each segment
+func (c *Controller) queryCpuRam(start time.Time, end time.Time, series string, join string, costType model.CostType, estimation *projection, isCpu bool) (result []stat, err error) {
+	pricingModels := c.getPricingModels()
+	statIndex := map[prometheus_model.Fingerprint]int{}
+	for _, segment := range pricingModels.Segments(start, end) {
+		durationPassed := segment.End.Sub(segment.Start).Round(time.Second)
+		promQuery := "avg_over_time(" + series + "[" + durationPassed.String() + ":])" + join
+		promResp, w, err := c.prometheus.Query(context.Background(), promQuery, segment.End)
 		if err != nil {
 			return nil, err
 		}
-		estimationValues, err = validateAndGetValuesPromResponse(promResp, w)
+		values, err := validateAndGetValuesPromResponse(promResp, w)
 		if err != nil {
 			return nil, err
 		}
-		slices.SortFunc(estimationValues, func(a, b *prometheus_model.Sample) int {
-			return int(a.Metric.FastFingerprint() - b.Metric.FastFingerprint())
-		})
+		for _, element := range values {
+			i, ok := statIndex[element.Metric.Fingerprint()]
+			if !ok {
+				i = len(result)
+				statIndex[element.Metric.Fingerprint()] = i
+				result = append(result, stat{
+					Labels: element.Metric,
+					CostWithEstimation: model.CostWithEstimation{
+						Month: model.CostEntry{},
+					},
+				})
+			}
+			namespace := string(element.Metric["namespace"])
+			if isCpu {
+				result[i].CostWithEstimation.Month.Cpu = result[i].CostWithEstimation.Month.Cpu.Add(model.ToDecimal(segment.CPUPrice(namespace, costType) * float64(element.Value) * durationPassed.Hours()))
+				result[i].CostWithEstimation.Month.CpuHours += float64(element.Value) * durationPassed.Hours()
+			} else {
+				result[i].CostWithEstimation.Month.Ram = result[i].CostWithEstimation.Month.Ram.Add(model.ToDecimal(segment.RAMPrice(namespace, costType) * float64(element.Value) * durationPassed.Hours() / 1000000000)) // cost * avg-usage * hours-progressed  / correction-bytes-in-gb
+				result[i].CostWithEstimation.Month.RamGbHours += float64(element.Value) * durationPassed.Hours() / 1000000000
+			}
+		}
 	}
 
-	for _, element := range values {
-		stat := stat{
-			Labels: element.Metric,
-			CostWithEstimation: model.CostWithEstimation{
-				Month: model.CostEntry{},
-			},
-		}
+	if estimation == nil {
+		return
+	}
+	estimationIndex, err := c.queryVectorIndex(estimation.strategy.query(series, estimation.start, estimation.end)+join, estimation.start)
+	if err != nil {
+		return nil, err
+	}
+	deviationIndex, err := c.queryVectorIndex(estimation.strategy.deviation(series)+join, estimation.start)
+	if err != nil {
+		return nil, err
+	}
+
+	hoursRemaining := estimation.hours()
+	for i := range result {
+		fingerprint := result[i].Labels.Fingerprint()
+		namespace := string(result[i].Labels["namespace"])
+		month := result[i].CostWithEstimation.Month
+		value := float64(estimationIndex[fingerprint])
+		lower, upper := estimationBounds(value, float64(deviationIndex[fingerprint]))
 		if isCpu {
-			stat.CostWithEstimation.Month.Cpu = c.pricingModel.CPU * float64(element.Value) * durationPassed.Hours()
-		} else {
-			stat.CostWithEstimation.Month.Ram = c.pricingModel.RAM * float64(element.Value) * durationPassed.Hours() / 1000000000 // cost * avg-usage * hours-progressed  / correction-bytes-in-gb
-		}
-		if estimationValues != nil {
-			i, ok := slices.BinarySearchFunc(estimationValues, element, func(a, b *prometheus_model.Sample) int {
-				return int(a.Metric.FastFingerprint() - b.Metric.FastFingerprint())
+			priceHoursRemaining := pricingModels.PriceHours(estimation.start, estimation.end, func(m model.PricingModel) float64 {
+				return m.CPUPrice(namespace, costType)
 			})
-			stat.CostWithEstimation.EstimationMonth = model.CostEntry{}
-			if isCpu {
-				if ok {
-					stat.CostWithEstimation.EstimationMonth.Cpu = stat.CostWithEstimation.Month.Cpu + c.pricingModel.CPU*float64(estimationValues[i].Value)*durationRemaining.Hours()
-				} else {
-					stat.CostWithEstimation.EstimationMonth.Cpu = stat.CostWithEstimation.Month.Cpu
+			entry := func(value float64) model.CostEntry {
+				return model.CostEntry{
+					Cpu:      month.Cpu.Add(model.ToDecimal(value * priceHoursRemaining)),
+					CpuHours: month.CpuHours + value*hoursRemaining,
 				}
-			} else {
-				if ok {
-					stat.CostWithEstimation.EstimationMonth.Ram = stat.CostWithEstimation.Month.Ram + c.pricingModel.RAM*float64(estimationValues[i].Value)*durationRemaining.Hours()/1000000000
-				} else {
-					stat.CostWithEstimation.EstimationMonth.Ram = stat.CostWithEstimation.Month.Ram
+			}
+			result[i].CostWithEstimation.EstimationMonth = entry(value)
+			result[i].CostWithEstimation.EstimationMonthLower = entry(lower)
+			result[i].CostWithEstimation.EstimationMonthUpper = entry(upper)
+		} else {
+			priceHoursRemaining := pricingModels.PriceHours(estimation.start, estimation.end, func(m model.PricingModel) float64 {
+				return m.RAMPrice(namespace, costType)
+			})
+			entry := func(value float64) model.CostEntry {
+				return model.CostEntry{
+					Ram:        month.Ram.Add(model.ToDecimal(value * priceHoursRemaining / 1000000000)),
+					RamGbHours: month.RamGbHours + value*hoursRemaining/1000000000,
 				}
 			}
+			result[i].CostWithEstimation.EstimationMonth = entry(value)
+			result[i].CostWithEstimation.EstimationMonthLower = entry(lower)
+			result[i].CostWithEstimation.EstimationMonthUpper = entry(upper)
 		}
-		result = append(result, stat)
 	}
 	return
+}
 
+// queryVectorIndex returns the values of the instant query by the fingerprint of their labels
+func (c *Controller) queryVectorIndex(query string, ts time.Time) (map[prometheus_model.Fingerprint]prometheus_model.SampleValue, error) {
+	promResp, w, err := c.prometheus.Query(context.Background(), query, ts)
+	if err != nil {
+		return nil, err
+	}
+	values, err := validateAndGetValuesPromResponse(promResp, w)
+	if err != nil {
+		return nil, err
+	}
+	index := map[prometheus_model.Fingerprint]prometheus_model.SampleValue{}
+	for _, element := range values {
+		index[element.Metric.Fingerprint()] = element.Value
+	}
+	return index, nil
 }
 
-func (c *Controller) getStorageStats(filter *filter, estimationBasedOn *time.Duration) (result []stat, err error) {
+func (c *Controller) getStorageStats(filter *filter, costType model.CostType, estimation *projection) (result []stat, err error) {
 	err = checkPodFilterFullyValid(filter)
 	if err != nil {
 		return nil, err
 	}
 
 	result = []stat{}
-	promQuery := "avg_over_time"
-	baseQuery0 := "(namespace_persistentvolumeclaim:kube_persistentvolumeclaim_resource_requests_storage_bytes:avg_1h{"
-	if filter.Namespace != nil {
-		baseQuery0 += "namespace=\"" + *filter.Namespace + "\""
+	pricingModels := c.getPricingModels()
+	statIndex := map[prometheus_model.Fingerprint]int{}
+	for _, segment := range pricingModels.Segments(*filter.Start, *filter.End) {
+		durationPassed := segment.End.Sub(segment.Start).Round(time.Second)
+		promQuery := c.storageQuery(filter, "["+durationPassed.String()+":]")
+		promResp, w, err := c.prometheus.Query(context.Background(), promQuery, segment.End)
+		if err != nil {
+			return nil, err
+		}
+		values, err := validateAndGetValuesPromResponse(promResp, w)
+		if err != nil {
+			return nil, err
+		}
+		for _, element := range values {
+			delete(element.Metric, "container") // This is always "kube-state-metrics" and should not be considered for container costs
+			fingerprint := element.Metric.Fingerprint()
+			i, ok := statIndex[fingerprint]
+			if !ok {
+				i = len(result)
+				statIndex[fingerprint] = i
+				result = append(result, stat{
+					Labels: element.Metric,
+					CostWithEstimation: model.CostWithEstimation{
+						Month: model.CostEntry{},
+					},
+				})
+			}
+			price := segment.StoragePrice(string(element.Metric["namespace"]), costType, string(element.Metric["storageclass"]))
+			result[i].CostWithEstimation.Month.Storage = result[i].CostWithEstimation.Month.Storage.Add(model.ToDecimal(price * float64(element.Value) * durationPassed.Hours() / 1000000000)) // cost * avg-size * hours-progressed / correction-bytes-in-gb
+			result[i].CostWithEstimation.Month.StorageGbHours += float64(element.Value) * durationPassed.Hours() / 1000000000
+		}
 	}
 
-	baseQuery0 += "}"
-	durationPassed := filter.End.Sub(*filter.Start).Round(time.Second)
-	promQuery += baseQuery0 + "[" + durationPassed.String() + ":]"
-
-	baseQuery1 := ") * on (namespace, persistentvolumeclaim) group_right() kube_pod_spec_volumes_persistentvolumeclaims_info{container=\"kube-state-metrics\""
-	if filter.Namespace != nil {
-		baseQuery1 += ", namespace=\"" + *filter.Namespace + "\""
+	if estimation == nil {
+		return
 	}
-	baseQuery1 += "} * on (namespace, pod) group_left(" + c.config.CustomPrometheusLabels + ") kube_pod_labels{container=\"kube-state-metrics\""
-	if filter.Namespace != nil {
-		baseQuery1 += ", namespace=\"" + *filter.Namespace + "\""
+	estimationIndex, err := c.storageIndex(c.storageQueryWith(filter, func(series string) string {
+		return estimation.strategy.query(series, estimation.start, estimation.end)
+	}), estimation.start)
+	if err != nil {
+		return nil, err
+	}
+	deviationIndex, err := c.storageIndex(c.storageQueryWith(filter, estimation.strategy.deviation), estimation.start)
+	if err != nil {
+		return nil, err
+	}
+	hoursRemaining := estimation.hours()
+	for i := range result {
+		fingerprint := result[i].Labels.Fingerprint()
+		month := result[i].CostWithEstimation.Month
+		size := estimationIndex[fingerprint]
+		lower, upper := estimationBounds(size, deviationIndex[fingerprint])
+		storagePriceHoursRemaining := pricingModels.PriceHours(estimation.start, estimation.end, func(m model.PricingModel) float64 {
+			return m.StoragePrice(string(result[i].Labels["namespace"]), costType, string(result[i].Labels["storageclass"]))
+		})
+		entry := func(size float64) model.CostEntry {
+			return model.CostEntry{
+				Storage:        month.Storage.Add(model.ToDecimal(size * storagePriceHoursRemaining / 1000000000)), // cost * avg-size * hours-remaining / correction-bytes-in-gb
+				StorageGbHours: month.StorageGbHours + size*hoursRemaining/1000000000,
+			}
+		}
+		result[i].CostWithEstimation.EstimationMonth = entry(size)
+		result[i].CostWithEstimation.EstimationMonthLower = entry(lower)
+		result[i].CostWithEstimation.EstimationMonthUpper = entry(upper)
 	}
-	baseQuery1 += getLabelFilterStr(filter.Labels) + "}"
-	promQuery += baseQuery1
-	promResp, w, err := c.prometheus.Query(context.Background(), promQuery, *filter.End)
+	return
+}
+
+// storageIndex returns the values of the storage query by the fingerprint of their labels without the container label
+func (c *Controller) storageIndex(query string, ts time.Time) (map[prometheus_model.Fingerprint]float64, error) {
+	promResp, w, err := c.prometheus.Query(context.Background(), query, ts)
 	if err != nil {
 		return nil, err
 	}
@@ -302,30 +495,160 @@ func (c *Controller) getStorageStats(filter *filter, estimationBasedOn *time.Dur
 	if err != nil {
 		return nil, err
 	}
-	now := time.Now() // This is fine as getting a prediction and providing start and end times is not allowed
-	endOfMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC)
-	durationRemaining := endOfMonth.Sub(now)
-
+	index := map[prometheus_model.Fingerprint]float64{}
 	for _, element := range values {
-		delete(element.Metric, "container") // This is always "kube-state-metrics" and should not be considered for container costs
-		stat := stat{
-			Labels: element.Metric,
-			CostWithEstimation: model.CostWithEstimation{
-				Month: model.CostEntry{
-					Storage: c.pricingModel.Storage * float64(element.Value) * durationPassed.Hours() / 1000000000, // cost * avg-size * hours-progressed / correction-bytes-in-gb
-				},
-			},
+		delete(element.Metric, "container")
+		index[element.Metric.Fingerprint()] = float64(element.Value)
+	}
+	return index, nil
+}
+
+// storageQuery returns the query of the average PVC sizes per pod within the subquery range like "[1h:]". PVCs are
+// labeled with their storage class, PVCs without kube_persistentvolumeclaim_info are kept without storage class.
+func (c *Controller) storageQuery(filter *filter, subqueryRange string) string {
+	return c.storageQueryWith(filter, func(series string) string {
+		return "avg_over_time(" + series + subqueryRange + ")"
+	})
+}
+
+// storageQueryWith returns the query of the PVC sizes per pod, aggregating the size series with aggregate
+func (c *Controller) storageQueryWith(filter *filter, aggregate func(series string) string) string {
+	namespaceFilter := ""
+	if filter.Namespace != nil {
+		namespaceFilter = "namespace=\"" + *filter.Namespace + "\""
+	}
+	pvcs := aggregate("namespace_persistentvolumeclaim:kube_persistentvolumeclaim_resource_requests_storage_bytes:avg_1h{"+namespaceFilter+"}") +
+		" * on (namespace, persistentvolumeclaim) group_right() kube_pod_spec_volumes_persistentvolumeclaims_info{container=\"kube-state-metrics\""
+	if filter.Namespace != nil {
+		pvcs += ", " + namespaceFilter
+	}
+	pvcs += "}"
+
+	query := "((" + pvcs + ") * on (namespace, persistentvolumeclaim) group_left(storageclass) max by (namespace, persistentvolumeclaim, storageclass) (kube_persistentvolumeclaim_info{container=\"kube-state-metrics\""
+	if filter.Namespace != nil {
+		query += ", " + namespaceFilter
+	}
+	query += "}) or on (namespace, persistentvolumeclaim) (" + pvcs + ")) * on (namespace, pod) group_left(" + c.config.CustomPrometheusLabels + ") kube_pod_labels{container=\"kube-state-metrics\""
+	if filter.Namespace != nil {
+		query += ", " + namespaceFilter
+	}
+	query += getLabelFilterStr(filter.Labels) + "}"
+	return query
+}
+
+// getNetworkStats returns the network traffic of pods. The traffic is not available per container, so stats only
+// have the labels namespace and pod.
+func (c *Controller) getNetworkStats(filter *filter, costType model.CostType, estimation *projection) (result []stat, err error) {
+	err = checkPodFilterFullyValid(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	selector, join := c.networkQueryParts(filter)
+
+	pricingModels := c.getPricingModels()
+	statIndex := map[prometheus_model.Fingerprint]int{}
+	remaining := map[prometheus_model.Fingerprint]model.CostWithEstimation{} // estimated cost of the projection
+	for _, direction := range networkDirections {
+		for _, segment := range pricingModels.Segments(*filter.Start, *filter.End) {
+			durationPassed := segment.End.Sub(segment.Start).Round(time.Second)
+			promQuery := "sum by (namespace, pod) (increase(" + direction.metric + selector + "[" + durationPassed.String() + "]))" + join
+			promResp, w, err := c.prometheus.Query(context.Background(), promQuery, segment.End)
+			if err != nil {
+				return nil, err
+			}
+			values, err := validateAndGetValuesPromResponse(promResp, w)
+			if err != nil {
+				return nil, err
+			}
+			for _, element := range values {
+				i, ok := statIndex[element.Metric.Fingerprint()]
+				if !ok {
+					i = len(result)
+					statIndex[element.Metric.Fingerprint()] = i
+					result = append(result, stat{
+						Labels: element.Metric,
+						CostWithEstimation: model.CostWithEstimation{
+							Month: model.CostEntry{},
+						},
+					})
+				}
+				gb := float64(element.Value) / 1000000000
+				result[i].CostWithEstimation.Month.Network = result[i].CostWithEstimation.Month.Network.Add(model.ToDecimal(gb * direction.price(segment.PricingModel)))
+				result[i].CostWithEstimation.Month.NetworkGb += gb
+			}
+		}
+
+		if estimation == nil {
+			continue
 		}
-		if estimationBasedOn != nil {
-			// Since we are calculating cost based on the PVC size and changes aren't common, just assume no changes and calculate cost based on time remaining
-			stat.CostWithEstimation.EstimationMonth = model.CostEntry{}
-			stat.CostWithEstimation.EstimationMonth.Storage = stat.CostWithEstimation.Month.Storage + c.pricingModel.Storage*float64(element.Value)*durationRemaining.Hours()/1000000000 // cost * avg-size * hours-progressed / correction-bytes-in-gb
+		rate := "sum by (namespace, pod) (rate(" + direction.metric + selector + "[5m]))"
+		estimationIndex, err := c.queryVectorIndex(estimation.strategy.query(rate, estimation.start, estimation.end)+join, estimation.start)
+		if err != nil {
+			return nil, err
+		}
+		deviationIndex, err := c.queryVectorIndex(estimation.strategy.deviation(rate)+join, estimation.start)
+		if err != nil {
+			return nil, err
+		}
+		priceHoursRemaining := pricingModels.PriceHours(estimation.start, estimation.end, direction.price)
+		entry := func(bytesPerSecond float64) model.CostEntry {
+			gbPerHour := bytesPerSecond * 3600 / 1000000000
+			return model.CostEntry{
+				Network:   model.ToDecimal(gbPerHour * priceHoursRemaining),
+				NetworkGb: gbPerHour * estimation.hours(),
+			}
+		}
+		for fingerprint, value := range estimationIndex {
+			lower, upper := estimationBounds(float64(value), float64(deviationIndex[fingerprint]))
+			r := remaining[fingerprint]
+			r.Add(model.CostWithEstimation{
+				EstimationMonth:      entry(float64(value)),
+				EstimationMonthLower: entry(lower),
+				EstimationMonthUpper: entry(upper),
+			})
+			remaining[fingerprint] = r
 		}
-		result = append(result, stat)
+	}
+
+	if estimation == nil {
+		return
+	}
+	for i := range result {
+		r := remaining[result[i].Labels.Fingerprint()]
+		month := model.CostEntry{
+			Network:   result[i].CostWithEstimation.Month.Network,
+			NetworkGb: result[i].CostWithEstimation.Month.NetworkGb,
+		}
+		result[i].CostWithEstimation.EstimationMonth = month
+		result[i].CostWithEstimation.EstimationMonth.Add(r.EstimationMonth)
+		result[i].CostWithEstimation.EstimationMonthLower = month
+		result[i].CostWithEstimation.EstimationMonthLower.Add(r.EstimationMonthLower)
+		result[i].CostWithEstimation.EstimationMonthUpper = month
+		result[i].CostWithEstimation.EstimationMonthUpper.Add(r.EstimationMonthUpper)
 	}
 	return
 }
 
+// networkQueryParts returns the selector of the network metrics and the join with the pod labels
+func (c *Controller) networkQueryParts(filter *filter) (selector string, join string) {
+	selector = "{"
+	if filter.Namespace != nil {
+		selector += "namespace=\"" + *filter.Namespace + "\""
+	}
+	selector += "}"
+	return selector, c.podLabelsJoin(filter)
+}
+
+// networkDirections are the network metrics and their prices
+var networkDirections = []struct {
+	metric string
+	price  func(m model.PricingModel) float64
+}{
+	{metric: "container_network_transmit_bytes_total", price: func(m model.PricingModel) float64 { return m.NetworkTransmit }},
+	{metric: "container_network_receive_bytes_total", price: func(m model.PricingModel) float64 { return m.NetworkReceive }},
+}
+
 func upsertPodStats(stats []stat, m map[string]stat, flags *upsertFlags) error {
 	for _, stat := range stats {
 		ns, ok := stat.Labels["namespace"]
@@ -345,25 +668,50 @@ func upsertPodStats(stats []stat, m map[string]stat, flags *upsertFlags) error {
 		if ok {
 			if flags.cpu {
 				entry.Month.Cpu = stat.Month.Cpu
+				entry.Month.CpuHours = stat.Month.CpuHours
 			}
 			if flags.ram {
 				entry.Month.Ram = stat.Month.Ram
+				entry.Month.RamGbHours = stat.Month.RamGbHours
 			}
 			if flags.storage {
 				entry.Month.Storage = stat.Month.Storage
+				entry.Month.StorageGbHours = stat.Month.StorageGbHours
 			}
-			if flags.cpuEstimation {
-				entry.EstimationMonth.Cpu = stat.EstimationMonth.Cpu
+			if flags.network {
+				entry.Month.Network = stat.Month.Network
+				entry.Month.NetworkGb = stat.Month.NetworkGb
 			}
-			if flags.ramEstimation {
-				entry.EstimationMonth.Ram = stat.EstimationMonth.Ram
+			estimations := []struct {
+				dst *model.CostEntry
+				src model.CostEntry
+			}{
+				{dst: &entry.EstimationMonth, src: stat.EstimationMonth},
+				{dst: &entry.EstimationMonthLower, src: stat.EstimationMonthLower},
+				{dst: &entry.EstimationMonthUpper, src: stat.EstimationMonthUpper},
 			}
-			if flags.storageEstimation {
-				entry.EstimationMonth.Storage = stat.EstimationMonth.Storage
+			for _, e := range estimations {
+				if flags.cpuEstimation {
+					e.dst.Cpu = e.src.Cpu
+					e.dst.CpuHours = e.src.CpuHours
+				}
+				if flags.ramEstimation {
+					e.dst.Ram = e.src.Ram
+					e.dst.RamGbHours = e.src.RamGbHours
+				}
+				if flags.storageEstimation {
+					e.dst.Storage = e.src.Storage
+					e.dst.StorageGbHours = e.src.StorageGbHours
+				}
+				if flags.networkEstimation {
+					e.dst.Network = e.src.Network
+					e.dst.NetworkGb = e.src.NetworkGb
+				}
 			}
 			for k, v := range stat.Labels {
 				entry.Labels[k] = v
 			}
+			entry.BillingBasis = model.CombineBillingBasis(entry.BillingBasis, stat.BillingBasis)
 		} else {
 			entry = stat
 		}
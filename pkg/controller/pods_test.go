@@ -0,0 +1,72 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	prometheus_model "github.com/prometheus/common/model"
+)
+
+func TestStorageQuery(t *testing.T) {
+	c := &Controller{config: &configuration.ConfigStruct{CustomPrometheusLabels: "label_user"}}
+	namespace := "analytics"
+	query := c.storageQuery(&filter{Namespace: &namespace}, "[1h:]")
+	pvcs := "avg_over_time(namespace_persistentvolumeclaim:kube_persistentvolumeclaim_resource_requests_storage_bytes:avg_1h{namespace=\"analytics\"}[1h:]) * on (namespace, persistentvolumeclaim) group_right() kube_pod_spec_volumes_persistentvolumeclaims_info{container=\"kube-state-metrics\", namespace=\"analytics\"}"
+	if strings.Count(query, pvcs) != 2 {
+		t.Errorf("expected the PVC sizes joined with and without storage class, got %v", query)
+	}
+	if !strings.Contains(query, "or on (namespace, persistentvolumeclaim) ("+pvcs+")") {
+		t.Errorf("expected PVCs without kube_persistentvolumeclaim_info as fallback, got %v", query)
+	}
+}
+
+func TestSetBillingBasis(t *testing.T) {
+	prometheus := &fakePrometheus{respond: func(query string) prometheus_model.Vector {
+		return prometheus_model.Vector{
+			{Metric: prometheus_model.Metric{"namespace": "ns", "pod": "a", "container": "c"}, Value: 1},
+			{Metric: prometheus_model.Metric{"namespace": "ns", "pod": "b", "container": "c"}, Value: 0},
+			{Metric: prometheus_model.Metric{"namespace": "ns", "pod": "c", "container": "c"}, Value: 0.5},
+		}
+	}}
+	c := &Controller{prometheus: prometheus, config: &configuration.ConfigStruct{}}
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	stats := []stat{
+		{Labels: prometheus_model.Metric{"namespace": "ns", "pod": "a", "container": "c", "label_user": "user"}},
+		{Labels: prometheus_model.Metric{"namespace": "ns", "pod": "b", "container": "c", "label_user": "user"}},
+		{Labels: prometheus_model.Metric{"namespace": "ns", "pod": "c", "container": "c", "label_user": "user"}},
+		{Labels: prometheus_model.Metric{"namespace": "ns", "pod": "d", "container": "c", "label_user": "user"}},
+	}
+	err := c.setBillingBasis(stats, model.BillingModeMax, "usage", "cpu", &filter{Start: &start, End: &end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []model.BillingMode{model.BillingModeUsage, model.BillingModeRequests, model.BillingBasisMixed, model.BillingModeUsage}
+	for i := range stats {
+		if stats[i].BillingBasis != expected[i] {
+			t.Errorf("expected %v for pod %v, got %v", expected[i], stats[i].Labels["pod"], stats[i].BillingBasis)
+		}
+	}
+	if !strings.HasPrefix(prometheus.queries[0], "avg_over_time((max by (namespace, pod, container) (usage) >bool on (namespace, pod, container) ") {
+		t.Errorf("expected the usage to be aggregated by container, got %v", prometheus.queries[0])
+	}
+}
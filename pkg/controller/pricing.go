@@ -0,0 +1,269 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+// getPricingModels returns the current pricing model versions. Callers should only call this once per calculation
+// to ensure a consistent pricing model even if it is replaced concurrently.
+func (c *Controller) getPricingModels() model.PricingModelVersions {
+	return *c.pricingModels.Load()
+}
+
+func (c *Controller) setPricingModels(versions model.PricingModelVersions) {
+	old := c.pricingModels.Swap(&versions)
+	if old == nil {
+		return
+	}
+	oldJson, _ := json.Marshal(*old)
+	newJson, _ := json.Marshal(versions)
+	if bytes.Equal(oldJson, newJson) {
+		log.Println("pricing model unchanged")
+		return
+	}
+	log.Printf("pricing model replaced\nold: %v\nnew: %v\n", string(oldJson), string(newJson))
+}
+
+// The store is the only source of pricing model versions used for calculations. Versions are added by admins using
+// the api and by importing the pricing model file. The file is imported on startup, on SIGHUP and whenever its content
+// differs from the content imported last, so that changes made while the service was down are not lost.
+// Admins win: file versions with the same valid_from as a version, which has last been changed using the api,
+// are skipped and logged. Versions, which have only been added using the api, are kept. Versions, which have last
+// been imported from the file and are no longer part of it, are removed.
+
+// ErrInvalidPricingModel is returned, if a pricing model version does not fit to the other versions
+var ErrInvalidPricingModel = errors.New("invalid pricing model")
+
+// ReloadPricingModels reads and validates the pricing model file and stores all versions of the file, which differ
+// from the stored versions and do not collide with versions of an admin. Stored file versions, which have been
+// removed from the file, are deleted.
+// If the file is invalid, the current pricing model is kept.
+func (c *Controller) ReloadPricingModels() error {
+	hash, err := hashFile(c.config.PricingModelFilePath)
+	if err != nil {
+		return err
+	}
+	versions, err := model.GetPricingModels(c.config.PricingModelFilePath)
+	if err != nil {
+		return err
+	}
+	stored, err := c.db.ListPricingModels()
+	if err != nil {
+		return err
+	}
+	authors, err := c.pricingModelAuthors()
+	if err != nil {
+		return err
+	}
+	inFile := map[time.Time]bool{}
+	for _, version := range versions {
+		inFile[version.ValidFrom.UTC()] = true
+		if containsPricingModel(stored, version) {
+			continue
+		}
+		if author, ok := authors[version.ValidFrom.UTC()]; ok && author != pricingFileAuthor {
+			log.Println("WARNING: skip version of pricing model file valid from", version.ValidFrom, "because it has been changed by", author)
+			continue
+		}
+		_, err = c.db.SetPricingModel(version, "", pricingFileAuthor, model.PricingModelVersions.Validate)
+		if err != nil {
+			return err
+		}
+	}
+	for _, version := range stored {
+		validFrom := version.ValidFrom.UTC()
+		if inFile[validFrom] || authors[validFrom] != pricingFileAuthor {
+			continue
+		}
+		log.Println("remove version of pricing model valid from", version.ValidFrom, "because it has been removed from the pricing model file")
+		_, err = c.db.DeletePricingModel(version.ValidFrom, "", pricingFileAuthor, model.PricingModelVersions.Validate)
+		if err != nil {
+			return err
+		}
+	}
+	err = c.db.SetPricingFileHash(hash)
+	if err != nil {
+		return err
+	}
+	return c.loadStoredPricingModels()
+}
+
+// initPricingModels imports the pricing model file, if it changed since the last import, and loads the stored versions
+func (c *Controller) initPricingModels() error {
+	changed, err := c.pricingModelFileChanged()
+	if err != nil {
+		return err
+	}
+	if changed {
+		return c.ReloadPricingModels()
+	}
+	return c.loadStoredPricingModels()
+}
+
+// pricingModelFileChanged checks if the content of the pricing model file differs from the content imported last
+func (c *Controller) pricingModelFileChanged() (bool, error) {
+	hash, err := hashFile(c.config.PricingModelFilePath)
+	if err != nil {
+		return false, err
+	}
+	imported, err := c.db.GetPricingFileHash()
+	if err != nil {
+		return false, err
+	}
+	return hash != imported, nil
+}
+
+// pricingModelAuthors returns the author of the last change of all stored versions by ValidFrom. Versions, which
+// have been imported from the pricing model file, have the author pricingFileAuthor.
+func (c *Controller) pricingModelAuthors() (map[time.Time]string, error) {
+	changes, err := c.db.ListPricingChanges()
+	if err != nil {
+		return nil, err
+	}
+	authors := map[time.Time]string{}
+	for _, change := range changes {
+		if change.Deleted {
+			delete(authors, change.New.ValidFrom.UTC())
+			continue
+		}
+		authors[change.New.ValidFrom.UTC()] = change.Username
+	}
+	return authors, nil
+}
+
+func (c *Controller) loadStoredPricingModels() error {
+	versions, err := c.db.ListPricingModels()
+	if err != nil {
+		return err
+	}
+	err = versions.Validate()
+	if err != nil {
+		return err
+	}
+	c.setPricingModels(versions)
+	return nil
+}
+
+const pricingFileAuthor = "pricing file"
+
+func containsPricingModel(versions model.PricingModelVersions, version model.PricingModel) bool {
+	expected, err := json.Marshal(version)
+	if err != nil {
+		return false
+	}
+	for _, v := range versions {
+		if !v.ValidFrom.Equal(version.ValidFrom) {
+			continue
+		}
+		actual, err := json.Marshal(v)
+		return err == nil && bytes.Equal(expected, actual)
+	}
+	return false
+}
+
+// GetPricingModel returns the currently valid pricing model.
+// Non-admins only receive the consumer specific api call prices of their own clients.
+func (c *Controller) GetPricingModel(username string, admin bool) model.PricingModel {
+	current := c.getPricingModels().At(time.Now())
+	if admin {
+		return current
+	}
+	return current.Public(username)
+}
+
+// SetPricingModel adds a new pricing model version or replaces the version with the same ValidFrom and uses it
+// immediately. If no ValidFrom is set, the version is valid from now on.
+func (c *Controller) SetPricingModel(version model.PricingModel, userId string, username string) (model.PricingChange, error) {
+	if version.ValidFrom.IsZero() {
+		version.ValidFrom = time.Now().UTC()
+	}
+	change, err := c.db.SetPricingModel(version, userId, username, func(versions model.PricingModelVersions) error {
+		err := versions.Validate()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPricingModel, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return change, err
+	}
+	return change, c.loadStoredPricingModels()
+}
+
+func (c *Controller) GetPricingHistory() ([]model.PricingChange, error) {
+	return c.db.ListPricingChanges()
+}
+
+// watchPricingModel reloads the pricing model on SIGHUP and whenever the content of the pricing model file changes
+func (c *Controller) watchPricingModel(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		tick = ticker.C
+		defer ticker.Stop()
+	}
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Println("received SIGHUP, reload pricing model")
+		case <-tick:
+			changed, err := c.pricingModelFileChanged()
+			if err != nil {
+				log.Println("ERROR: unable to check pricing model file", err)
+				continue
+			}
+			if !changed {
+				continue
+			}
+			log.Println("pricing model file changed, reload pricing model")
+		}
+		err := c.ReloadPricingModels()
+		if err != nil {
+			log.Println("ERROR: unable to reload pricing model, keep using the current pricing model:", err)
+		}
+	}
+}
+
+func hashFile(filePath string) (hash string, err error) {
+	b, err := os.ReadFile(filePath)
+	if err != nil {
+		return hash, err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,96 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/database"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func TestPricingModelFileImport(t *testing.T) {
+	dir := t.TempDir()
+	db, err := database.New(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	file := filepath.Join(dir, "pricing_model.json")
+	writeFile := func(content string) {
+		err := os.WriteFile(file, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := &Controller{config: &configuration.ConfigStruct{PricingModelFilePath: file}, db: db}
+
+	writeFile(`[{"valid_from": "2024-01-01T00:00:00Z", "CPU": "1", "RAM": "1", "storage": "1"}, {"valid_from": "2024-02-01T00:00:00Z", "CPU": "2", "RAM": "1", "storage": "1"}]`)
+	err = c.initPricingModels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	_, err = c.SetPricingModel(model.PricingModel{ValidFrom: feb, CPU: 5}, "admin", "admin-name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the file is edited while the service is down
+	writeFile(`[{"valid_from": "2024-01-01T00:00:00Z", "CPU": "3", "RAM": "1", "storage": "1"}, {"valid_from": "2024-02-01T00:00:00Z", "CPU": "4", "RAM": "1", "storage": "1"}]`)
+	c = &Controller{config: c.config, db: db}
+	err = c.initPricingModels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := c.getPricingModels()
+	if len(versions) != 2 || versions[0].CPU != 3 {
+		t.Errorf("expected the file change to be imported, got %#v", versions)
+	}
+	if versions[1].CPU != 5 {
+		t.Errorf("expected the admin version to win, got %#v", versions[1])
+	}
+
+	changed, err := c.pricingModelFileChanged()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Error("expected the imported file to be unchanged")
+	}
+
+	// versions removed from the file are removed, versions of admins are kept
+	writeFile(`[{"valid_from": "2024-03-01T00:00:00Z", "CPU": "6", "RAM": "1", "storage": "1"}]`)
+	err = c.ReloadPricingModels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions = c.getPricingModels()
+	if len(versions) != 2 || versions[0].CPU != 5 || versions[1].CPU != 6 {
+		t.Errorf("expected the removed file version to be deleted, got %#v", versions)
+	}
+
+	_, err = c.SetPricingModel(model.PricingModel{ValidFrom: feb, CPU: 5, Currency: "USD"}, "admin", "admin-name")
+	if !errors.Is(err, ErrInvalidPricingModel) {
+		t.Errorf("expected invalid pricing model for differing currencies, got %v", err)
+	}
+}
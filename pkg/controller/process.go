@@ -30,14 +30,19 @@ import (
 	prometheus_model "github.com/prometheus/common/model"
 )
 
-func (c *Controller) GetProcessTree(userId string, skipEstimation bool, start *time.Time, end *time.Time) (processCost model.CostWithChildren, err error) {
+func (c *Controller) GetProcessTree(userId string, skipEstimation bool, estimation model.EstimationOptions, start *time.Time, end *time.Time) (processCost model.CostWithChildren, err error) {
 	timer := time.Now()
 
-	if (start == nil && end != nil) || (start != nil && end == nil) || (start != nil && !skipEstimation) {
-		return processCost, fmt.Errorf("must not provide only one of start or end. must not provide start and stop without skipEstimation")
+	start, end, err = c.checkStartEnd(skipEstimation, start, end)
+	if err != nil {
+		return processCost, err
 	}
-	if start == nil {
-		start, end = defaultStartEnd()
+	var proj *projection
+	if !skipEstimation {
+		proj, err = c.newProjection(model.CostTypeProcesses, estimation, *end)
+		if err != nil {
+			return processCost, err
+		}
 	}
 
 	processCost = model.CostWithChildren{
@@ -58,19 +63,21 @@ func (c *Controller) GetProcessTree(userId string, skipEstimation bool, start *t
 	if userProcessFactor > 0 {
 		for k, v := range c.config.ProcessCostSources {
 			filter := &statsFilter{
-				CPU:     true,
-				RAM:     true,
-				Storage: true,
+				CPU:        true,
+				RAM:        true,
+				Storage:    true,
+				Network:    true,
+				CostType:   model.CostTypeProcesses,
+				Estimation: proj,
 				filter: filter{
 					Namespace: &k,
 					Labels: map[string][]string{
 						"pod": v,
 					},
+					Start: start,
+					End:   end,
 				},
 			}
-			if !skipEstimation {
-				filter.PredictionBasedOn = &d24h
-			}
 			stats, err := c.getStats(filter)
 			if err != nil {
 				return processCost, err
@@ -80,52 +87,23 @@ func (c *Controller) GetProcessTree(userId string, skipEstimation bool, start *t
 				if !ok {
 					return processCost, errors.New("missing label pod")
 				}
-				name := string(nameLabel)
-				nameParts := strings.Split(string(nameLabel), "-")
-				i := len(nameParts)
-				if regexp.MustCompile(`.*-\d+$`).Match([]byte(name)) {
-					// is stateful set pod, they always end in -\d
-					i -= 1
-				} else {
-					// is something else, always ends in -xxxxxxxxx-xxxxx
-					i -= 2
-				}
-				name = strings.Join(nameParts[:i], "-")
+				name := processName(string(nameLabel))
 
 				child := model.CostWithChildren{
-					CostWithEstimation: model.CostWithEstimation{
-						Month: model.CostEntry{
-							Cpu:     stat.Month.Cpu * userProcessFactor,
-							Ram:     stat.Month.Ram * userProcessFactor,
-							Storage: stat.Month.Storage * userProcessFactor,
-						},
-					},
-					Children: map[string]model.CostWithChildren{},
-				}
-				if !skipEstimation {
-					child.CostWithEstimation.EstimationMonth = model.CostEntry{
-						Cpu:     stat.EstimationMonth.Cpu * userProcessFactor,
-						Ram:     stat.EstimationMonth.Ram * userProcessFactor,
-						Storage: stat.EstimationMonth.Storage * userProcessFactor,
-					}
+					CostWithEstimation: stat.CostWithEstimation.Scale(userProcessFactor),
+					BillingBasis:       stat.BillingBasis,
+					Children:           map[string]model.CostWithChildren{},
 				}
 				existingChild, ok := processCost.Children[name]
 				if ok {
 					existingChild.Add(child.CostWithEstimation)
+					existingChild.BillingBasis = model.CombineBillingBasis(existingChild.BillingBasis, child.BillingBasis)
 					processCost.Children[name] = existingChild
 				} else {
 					processCost.Children[name] = child
 				}
 
-				processCost.Month.Cpu = processCost.Month.Cpu + child.Month.Cpu
-				processCost.Month.Ram = processCost.Month.Ram + child.Month.Ram
-				processCost.Month.Storage = processCost.Month.Storage + child.Month.Storage
-
-				if !skipEstimation {
-					processCost.EstimationMonth.Cpu = processCost.EstimationMonth.Cpu + child.EstimationMonth.Cpu
-					processCost.EstimationMonth.Ram = processCost.EstimationMonth.Ram + child.EstimationMonth.Ram
-					processCost.EstimationMonth.Storage = processCost.EstimationMonth.Storage + child.EstimationMonth.Storage
-				}
+				processCost.Add(child.CostWithEstimation)
 
 				processDefinitionFactors, err := c.getProcessDefinitionFactors(name, userId, *start, *end)
 				if err != nil {
@@ -136,21 +114,9 @@ func (c *Controller) GetProcessTree(userId string, skipEstimation bool, start *t
 						continue
 					}
 					grandchild := model.CostWithChildren{
-						CostWithEstimation: model.CostWithEstimation{
-							Month: model.CostEntry{
-								Cpu:     child.Month.Cpu * factor,
-								Ram:     child.Month.Ram * factor,
-								Storage: child.Month.Storage * factor,
-							},
-						},
-						Children: map[string]model.CostWithChildren{},
-					}
-					if !skipEstimation {
-						grandchild.CostWithEstimation.EstimationMonth = model.CostEntry{
-							Cpu:     child.EstimationMonth.Cpu * factor,
-							Ram:     child.EstimationMonth.Ram * factor,
-							Storage: child.EstimationMonth.Storage * factor,
-						}
+						CostWithEstimation: child.CostWithEstimation.Scale(factor),
+						BillingBasis:       child.BillingBasis,
+						Children:           map[string]model.CostWithChildren{},
 					}
 					child.Children[processDefinition] = grandchild
 				}
@@ -159,27 +125,31 @@ func (c *Controller) GetProcessTree(userId string, skipEstimation bool, start *t
 	}
 
 	marshallerCostTotal := model.CostWithEstimation{}
+	var marshallerBillingBasis model.BillingMode
 	for k, v := range c.config.MarshallingCostSources {
 		filter := &statsFilter{
-			CPU:     true,
-			RAM:     true,
-			Storage: true,
+			CPU:        true,
+			RAM:        true,
+			Storage:    true,
+			Network:    true,
+			CostType:   model.CostTypeProcesses,
+			Estimation: proj,
 			filter: filter{
 				Namespace: &k,
 				Labels: map[string][]string{
 					"pod": v,
 				},
+				Start: start,
+				End:   end,
 			},
 		}
-		if !skipEstimation {
-			filter.PredictionBasedOn = &d24h
-		}
 		stats, err := c.getStats(filter)
 		if err != nil {
 			return processCost, err
 		}
 		tree := buildTree(stats, "namespace")
 		marshallerCostTotal.Add(tree.CostWithEstimation)
+		marshallerBillingBasis = model.CombineBillingBasis(marshallerBillingBasis, tree.BillingBasis)
 	}
 
 	timer2 = time.Now()
@@ -197,32 +167,11 @@ func (c *Controller) GetProcessTree(userId string, skipEstimation bool, start *t
 		}
 		c.logDebug("ProcessTree: getProcessMarshallerFactor " + time.Since(timer2).String())
 
-		marshallerCostProcesses := model.CostWithEstimation{
-			Month: model.CostEntry{
-				Cpu:     marshallerCostTotal.Month.Cpu * processMarshallerFactor,
-				Ram:     marshallerCostTotal.Month.Ram * processMarshallerFactor,
-				Storage: marshallerCostTotal.Month.Storage * processMarshallerFactor,
-			},
-			EstimationMonth: model.CostEntry{
-				Cpu:     marshallerCostTotal.EstimationMonth.Cpu * processMarshallerFactor,
-				Ram:     marshallerCostTotal.EstimationMonth.Ram * processMarshallerFactor,
-				Storage: marshallerCostTotal.EstimationMonth.Storage * processMarshallerFactor,
-			},
-		}
+		marshallerCostProcesses := marshallerCostTotal.Scale(processMarshallerFactor)
 		marshallerCostUser := model.CostWithChildren{
-			CostWithEstimation: model.CostWithEstimation{
-				Month: model.CostEntry{
-					Cpu:     marshallerCostProcesses.Month.Cpu * userMarshallerFactor,
-					Ram:     marshallerCostProcesses.Month.Ram * userMarshallerFactor,
-					Storage: marshallerCostProcesses.Month.Storage * userMarshallerFactor,
-				},
-				EstimationMonth: model.CostEntry{
-					Cpu:     marshallerCostProcesses.EstimationMonth.Cpu * userMarshallerFactor,
-					Ram:     marshallerCostProcesses.EstimationMonth.Ram * userMarshallerFactor,
-					Storage: marshallerCostProcesses.EstimationMonth.Storage * userMarshallerFactor,
-				},
-			},
-			Children: map[string]model.CostWithChildren{},
+			CostWithEstimation: marshallerCostProcesses.Scale(userMarshallerFactor),
+			BillingBasis:       marshallerBillingBasis,
+			Children:           map[string]model.CostWithChildren{},
 		}
 		processCost.Children["marshalling"] = marshallerCostUser
 	}
@@ -235,11 +184,15 @@ func (c *Controller) GetProcessTree(userId string, skipEstimation bool, start *t
 	c.logDebug("ProcessTree: getUserProcessIoFactor " + time.Since(timer2).String())
 	if userProcessIoFactor != 0 {
 		processIoCostTotal := model.CostWithEstimation{}
+		var processIoBillingBasis model.BillingMode
 		for k, v := range c.config.ProcessIoCostSources {
 			filter := &statsFilter{
-				CPU:     true,
-				RAM:     true,
-				Storage: true,
+				CPU:        true,
+				RAM:        true,
+				Storage:    true,
+				Network:    true,
+				CostType:   model.CostTypeProcesses,
+				Estimation: proj,
 				filter: filter{
 					Namespace: &k,
 					Labels: map[string][]string{
@@ -249,41 +202,44 @@ func (c *Controller) GetProcessTree(userId string, skipEstimation bool, start *t
 					End:   end,
 				},
 			}
-			if !skipEstimation {
-				filter.PredictionBasedOn = &d24h
-			}
 			stats, err := c.getStats(filter)
 			if err != nil {
 				return processCost, err
 			}
 			tree := buildTree(stats, "namespace")
 			processIoCostTotal.Add(tree.CostWithEstimation)
+			processIoBillingBasis = model.CombineBillingBasis(processIoBillingBasis, tree.BillingBasis)
 		}
 
 		processIoCostUser := model.CostWithChildren{
-			CostWithEstimation: model.CostWithEstimation{
-				Month: model.CostEntry{
-					Cpu:     processIoCostTotal.Month.Cpu * userProcessIoFactor,
-					Ram:     processIoCostTotal.Month.Ram * userProcessIoFactor,
-					Storage: processIoCostTotal.Month.Storage * userProcessIoFactor,
-				},
-			},
-			Children: map[string]model.CostWithChildren{},
-		}
-		if !skipEstimation {
-			processIoCostUser.CostWithEstimation.EstimationMonth = model.CostEntry{
-				Cpu:     processIoCostTotal.EstimationMonth.Cpu * userProcessIoFactor,
-				Ram:     processIoCostTotal.EstimationMonth.Ram * userProcessIoFactor,
-				Storage: processIoCostTotal.EstimationMonth.Storage * userProcessIoFactor,
-			}
+			CostWithEstimation: processIoCostTotal.Scale(userProcessIoFactor),
+			BillingBasis:       processIoBillingBasis,
+			Children:           map[string]model.CostWithChildren{},
 		}
 		processCost.Children["process-io"] = processIoCostUser
 	}
+	for _, child := range processCost.Children {
+		processCost.BillingBasis = model.CombineBillingBasis(processCost.BillingBasis, child.BillingBasis)
+	}
 	c.logDebug("ProcessTree " + time.Since(timer).String())
 
 	return processCost, nil
 }
 
+// processName returns the name of the workload of the pod
+func processName(pod string) string {
+	nameParts := strings.Split(pod, "-")
+	i := len(nameParts)
+	if regexp.MustCompile(`.*-\d+$`).Match([]byte(pod)) {
+		// is stateful set pod, they always end in -\d
+		i -= 1
+	} else {
+		// is something else, always ends in -xxxxxxxxx-xxxxx
+		i -= 2
+	}
+	return strings.Join(nameParts[:i], "-")
+}
+
 func (c *Controller) getUserProcessFactor(userId string, start time.Time, end time.Time) (float64, error) {
 	return c.getValueFromPrometheus(c.config.UserProcessCostFractionQuery, userId, start, end)
 }
@@ -0,0 +1,230 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	prometheus_model "github.com/prometheus/common/model"
+)
+
+type processDefinitionCostsCacheEntry struct {
+	costs     map[string]float64 // cost of the last billing period by process definition id
+	owners    map[string]string  // user id by process definition id
+	tasks     map[string]float64 // number of tasks by process definition id, nil if not configured
+	enteredAt time.Time
+}
+
+// ErrNotConfigured is returned, if a request needs optional config, which is missing
+var ErrNotConfigured = errors.New("not configured")
+
+// GetProcessEstimation estimates the monthly cost of a process definition from the costs of the process definitions
+// in the last billing period. If processDefinitionId is set, the cost of this definition is returned, if it is a
+// definition of the user. If tasks is set, the costs per task of the definitions of all users are scaled to the
+// number of tasks. Otherwise, the costs of the definitions of the user are used.
+func (c *Controller) GetProcessEstimation(userId string, processDefinitionId string, tasks int64) (*model.Estimation, error) {
+	definitions, err := c.getProcessDefinitionCosts()
+	if err != nil {
+		return nil, err
+	}
+	costs := []float64{}
+	switch {
+	case processDefinitionId != "":
+		cost, ok := definitions.costs[processDefinitionId]
+		if !ok || definitions.owners[processDefinitionId] != userId {
+			return nil, fmt.Errorf("%w: no cost of process definition %v of the user in the last billing period", ErrNotFound, processDefinitionId)
+		}
+		costs = append(costs, cost)
+	case tasks > 0:
+		if definitions.tasks == nil {
+			return nil, fmt.Errorf("%w: missing process_definition_task_count_query config", ErrNotConfigured)
+		}
+		for id, cost := range definitions.costs {
+			if count := definitions.tasks[id]; count > 0 {
+				costs = append(costs, cost/count*float64(tasks))
+			}
+		}
+	default:
+		for id, cost := range definitions.costs {
+			if definitions.owners[id] == userId {
+				costs = append(costs, cost)
+			}
+		}
+		if len(costs) == 0 {
+			return nil, fmt.Errorf("%w: no cost of process definitions of the user in the last billing period", ErrNotFound)
+		}
+	}
+	min, max, mean, median := calcMinMaxMeanMedian(costs)
+	return &model.Estimation{Min: min, Max: max, Mean: mean, Median: median}, nil
+}
+
+// ErrInvalidBpmn is returned, if a process model can not be parsed
+var ErrInvalidBpmn = errors.New("invalid bpmn")
+
+// bpmnTaskElements are the BPMN elements, which are counted as tasks of a process model
+var bpmnTaskElements = map[string]bool{
+	"task":             true,
+	"serviceTask":      true,
+	"sendTask":         true,
+	"receiveTask":      true,
+	"userTask":         true,
+	"manualTask":       true,
+	"businessRuleTask": true,
+	"scriptTask":       true,
+}
+
+// GetBpmnProcessEstimation estimates the monthly cost of a process model, which does not need to be deployed yet,
+// by the number of its tasks. See GetProcessEstimation.
+func (c *Controller) GetBpmnProcessEstimation(userId string, bpmn io.Reader) (*model.Estimation, error) {
+	tasks, err := countBpmnTasks(bpmn)
+	if err != nil {
+		return nil, err
+	}
+	if tasks == 0 {
+		return nil, fmt.Errorf("%w: process model has no tasks", ErrInvalidBpmn)
+	}
+	return c.GetProcessEstimation(userId, "", tasks)
+}
+
+// countBpmnTasks returns the number of tasks in the BPMN XML
+func countBpmnTasks(bpmn io.Reader) (tasks int64, err error) {
+	decoder := xml.NewDecoder(bpmn)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return tasks, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidBpmn, err)
+		}
+		if element, ok := token.(xml.StartElement); ok && bpmnTaskElements[element.Name.Local] {
+			tasks++
+		}
+	}
+}
+
+// getProcessDefinitionCosts distributes the cost of the process cost sources in the last billing period to the
+// process definitions of all users, like GetProcessTree does for a single user. The fractions of all users are
+// queried at once, so that the number of queries does not grow with the number of users. Results are cached for
+// cacheValid.
+func (c *Controller) getProcessDefinitionCosts() (entry processDefinitionCostsCacheEntry, err error) {
+	c.processDefinitionCostsMux.Lock()
+	defer c.processDefinitionCostsMux.Unlock()
+	if c.processDefinitionCosts != nil && c.processDefinitionCosts.enteredAt.Add(cacheValid).After(time.Now()) {
+		return *c.processDefinitionCosts, nil
+	}
+	if c.config.ProcessCostFractionByUserQuery == "" || c.config.ProcessDefinitionCostFractionByUserQuery == "" {
+		return entry, fmt.Errorf("%w: missing process_cost_fraction_by_user_query or process_definition_cost_fraction_by_user_query config", ErrNotConfigured)
+	}
+	start, end, err := c.billing.Preset(model.PeriodLastMonth, time.Now())
+	if err != nil {
+		return entry, err
+	}
+
+	processCosts := map[string]float64{}
+	for k, v := range c.config.ProcessCostSources {
+		stats, err := c.getStats(&statsFilter{
+			CPU:      true,
+			RAM:      true,
+			Storage:  true,
+			Network:  true,
+			CostType: model.CostTypeProcesses,
+			filter: filter{
+				Namespace: &k,
+				Labels: map[string][]string{
+					"pod": v,
+				},
+				Start: &start,
+				End:   &end,
+			},
+		})
+		if err != nil {
+			return entry, err
+		}
+		for _, stat := range stats {
+			nameLabel, ok := stat.Labels["pod"]
+			if !ok {
+				return entry, errors.New("missing label pod")
+			}
+			processCosts[processName(string(nameLabel))] += stat.Month.Total().InexactFloat64()
+		}
+	}
+
+	fractions, err := c.getVectorFromPrometheus(c.config.ProcessCostFractionByUserQuery, start, end)
+	if err != nil {
+		return entry, err
+	}
+	userProcessFactors := map[string]float64{}
+	for _, element := range fractions {
+		userProcessFactors[string(element.Metric["user_id"])] = sampleToFloat(element.Value)
+	}
+	entry.costs = map[string]float64{}
+	entry.owners = map[string]string{}
+	for name, cost := range processCosts {
+		instanceId, ok := c.config.ProcessCostSourceToInstanceIdPlaceholderForProcessDefCostFraction[name]
+		if !ok {
+			continue
+		}
+		increases, err := c.getVectorFromPrometheus(strings.ReplaceAll(c.config.ProcessDefinitionCostFractionByUserQuery, "$instance_id", instanceId), start, end)
+		if err != nil {
+			return entry, err
+		}
+		// the definition factors of each user sum up to 1, like in getProcessDefinitionFactors
+		userSums := map[string]float64{}
+		for _, element := range increases {
+			userSums[string(element.Metric["user_id"])] += sampleToFloat(element.Value)
+		}
+		for _, element := range increases {
+			userId := string(element.Metric["user_id"])
+			processDefinition := string(element.Metric["process_definition_id"])
+			userProcessFactor := userProcessFactors[userId]
+			increase := sampleToFloat(element.Value)
+			if userId == "" || processDefinition == "" || userProcessFactor <= 0 || increase <= 0 {
+				continue
+			}
+			entry.costs[processDefinition] += cost * userProcessFactor * increase / userSums[userId]
+			entry.owners[processDefinition] = userId
+		}
+	}
+
+	if c.config.ProcessDefinitionTaskCountQuery != "" {
+		entry.tasks, err = c.getValueMapFromPrometheus(c.config.ProcessDefinitionTaskCountQuery, "", start, end)
+		if err != nil {
+			return entry, err
+		}
+	}
+	entry.enteredAt = time.Now()
+	c.processDefinitionCosts = &entry
+	return entry, nil
+}
+
+// getVectorFromPrometheus returns the vector of the query at end, $__range is replaced with the duration from start to end
+func (c *Controller) getVectorFromPrometheus(query string, start time.Time, end time.Time) (prometheus_model.Vector, error) {
+	query = strings.ReplaceAll(query, "$__range", end.Sub(start).Round(time.Second).String())
+	resp, w, err := c.prometheus.Query(context.Background(), query, end)
+	if err != nil {
+		return nil, err
+	}
+	return validateAndGetValuesPromResponse(resp, w)
+}
@@ -19,10 +19,15 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"math"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	prometheus_model "github.com/prometheus/common/model"
 )
 
 func TestGetCostTree(t *testing.T) {
@@ -49,7 +54,7 @@ func TestGetCostTree(t *testing.T) {
 		return
 	}
 
-	result, err := ctrl.GetProcessTree(userId, false, nil, nil)
+	result, err := ctrl.GetProcessTree(userId, false, model.EstimationOptions{}, nil, nil)
 	if err != nil {
 		t.Error(err)
 		return
@@ -87,7 +92,7 @@ func TestGetUserProcessFactor(t *testing.T) {
 		return
 	}
 
-	start, end := defaultStartEnd()
+	start, end := ctrl.defaultStartEnd()
 
 	t.Log(ctrl.getUserProcessFactor(userId, *start, *end))
 }
@@ -115,7 +120,7 @@ func TestGetProcessDefinitionFactor(t *testing.T) {
 		return
 	}
 
-	start, end := defaultStartEnd()
+	start, end := ctrl.defaultStartEnd()
 
 	t.Log(ctrl.getProcessDefinitionFactors("__unallocated__/process-task-worker/deployment:pessimistic-worker", userId, *start, *end))
 }
@@ -149,3 +154,158 @@ func TestGetProcessDefinitionFactorFactor(t *testing.T) {
 
 	t.Log(ctrl.getValueMapFromPrometheus("sum( increase(external_task_worker_task_command_send_count_vec[$__range]) ) by (process_definition_id)", userId, start, end))
 }
+
+func TestProcessName(t *testing.T) {
+	if name := processName("engine-db-0"); name != "engine-db" {
+		t.Error(name)
+	}
+	if name := processName("pessimistic-worker-5d8f7c9b4-x2k9z"); name != "pessimistic-worker" {
+		t.Error(name)
+	}
+}
+
+func TestGetProcessEstimation(t *testing.T) {
+	c := &Controller{processDefinitionCosts: &processDefinitionCostsCacheEntry{
+		costs:     map[string]float64{"own": 4, "other": 8},
+		owners:    map[string]string{"own": "user", "other": "someone"},
+		tasks:     map[string]float64{"own": 2, "other": 2},
+		enteredAt: time.Now(),
+	}}
+	estimation, err := c.GetProcessEstimation("user", "own", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimation.Mean != 4 {
+		t.Errorf("unexpected estimation %#v", estimation)
+	}
+	_, err = c.GetProcessEstimation("user", "other", 0)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected definitions of other users to be not found, got %v", err)
+	}
+	estimation, err = c.GetProcessEstimation("user", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimation.Min != 4 || estimation.Max != 4 {
+		t.Errorf("expected only definitions of the user, got %#v", estimation)
+	}
+	_, err = c.GetProcessEstimation("unknown", "", 0)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected user without definitions to be not found, got %v", err)
+	}
+
+	bpmn := `<bpmn:definitions xmlns:bpmn="http://www.omg.org/spec/BPMN/20100524/MODEL">
+	<bpmn:process id="p"><bpmn:startEvent id="s"/><bpmn:serviceTask id="a"/><bpmn:userTask id="b"/><bpmn:endEvent id="e"/></bpmn:process>
+</bpmn:definitions>`
+	estimation, err = c.GetBpmnProcessEstimation("user", strings.NewReader(bpmn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimation.Min != 4 || estimation.Max != 8 {
+		t.Errorf("unexpected estimation of 2 tasks %#v", estimation)
+	}
+	_, err = c.GetBpmnProcessEstimation("user", strings.NewReader("<bpmn:definitions>"))
+	if !errors.Is(err, ErrInvalidBpmn) {
+		t.Errorf("expected invalid bpmn, got %v", err)
+	}
+}
+
+func TestGetProcessEstimationNotConfigured(t *testing.T) {
+	c := &Controller{config: &configuration.ConfigStruct{}}
+	_, err := c.GetProcessEstimation("user", "", 0)
+	if !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("expected missing fraction queries to be not configured, got %v", err)
+	}
+	c.processDefinitionCosts = &processDefinitionCostsCacheEntry{
+		costs:     map[string]float64{"own": 4},
+		owners:    map[string]string{"own": "user"},
+		enteredAt: time.Now(),
+	}
+	_, err = c.GetProcessEstimation("user", "", 2)
+	if !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("expected missing task count query to be not configured, got %v", err)
+	}
+}
+
+func TestCountBpmnTasks(t *testing.T) {
+	cases := map[string]int64{
+		`<definitions><process><startEvent/><task/><scriptTask/><endEvent/></process></definitions>`: 2,
+		`<bpmn:definitions xmlns:bpmn="http://www.omg.org/spec/BPMN/20100524/MODEL"><bpmn:process>
+			<bpmn:serviceTask id="a"><bpmn:incoming>f</bpmn:incoming></bpmn:serviceTask>
+			<bpmn:subProcess><bpmn:sendTask/><bpmn:receiveTask/></bpmn:subProcess>
+			<bpmn:sequenceFlow id="f"/>
+		</bpmn:process></bpmn:definitions>`: 3,
+		`<definitions><process><startEvent/><endEvent/></process></definitions>`: 0,
+	}
+	for bpmn, expected := range cases {
+		tasks, err := countBpmnTasks(strings.NewReader(bpmn))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tasks != expected {
+			t.Errorf("expected %v tasks, got %v in %v", expected, tasks, bpmn)
+		}
+	}
+	_, err := countBpmnTasks(strings.NewReader("<definitions><process></definitions>"))
+	if !errors.Is(err, ErrInvalidBpmn) {
+		t.Errorf("expected invalid bpmn, got %v", err)
+	}
+}
+
+func TestGetProcessDefinitionCosts(t *testing.T) {
+	prometheus := &fakePrometheus{respond: func(query string) prometheus_model.Vector {
+		switch {
+		case strings.HasPrefix(query, "fractions"):
+			return prometheus_model.Vector{
+				{Metric: prometheus_model.Metric{"user_id": "a"}, Value: 0.75},
+				{Metric: prometheus_model.Metric{"user_id": "b"}, Value: 0.25},
+			}
+		case strings.HasPrefix(query, "definitions"):
+			return prometheus_model.Vector{
+				{Metric: prometheus_model.Metric{"user_id": "a", "process_definition_id": "a1"}, Value: 1},
+				{Metric: prometheus_model.Metric{"user_id": "a", "process_definition_id": "a2"}, Value: 3},
+				{Metric: prometheus_model.Metric{"user_id": "b", "process_definition_id": "b1"}, Value: 5},
+			}
+		default:
+			// one cpu core of the worker
+			return prometheus_model.Vector{{Metric: prometheus_model.Metric{"namespace": "processes", "pod": "worker-5d8f7c9b4-x2k9z"}, Value: 1}}
+		}
+	}}
+	c := &Controller{prometheus: prometheus, config: &configuration.ConfigStruct{
+		ProcessCostSources: map[string][]string{"processes": {"worker.*"}},
+		ProcessCostSourceToInstanceIdPlaceholderForProcessDefCostFraction: map[string]string{"worker": "worker-metrics"},
+		ProcessCostFractionByUserQuery:                                    "fractions",
+		ProcessDefinitionCostFractionByUserQuery:                          "definitions{endpoint=\"$instance_id\"}",
+	}}
+	c.setPricingModels(model.PricingModelVersions{{CPU: 1}})
+
+	entry, err := c.getProcessDefinitionCosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fractionQueries := 0
+	for _, query := range prometheus.queries {
+		if strings.HasPrefix(query, "fractions") || strings.HasPrefix(query, "definitions") {
+			fractionQueries++
+		}
+		if strings.HasPrefix(query, "definitions") && query != "definitions{endpoint=\"worker-metrics\"}" {
+			t.Errorf("unexpected query %v", query)
+		}
+	}
+	if fractionQueries != 2 {
+		t.Errorf("expected one query for all users and one for each process, got %v", prometheus.queries)
+	}
+	total := entry.costs["a1"] + entry.costs["a2"] + entry.costs["b1"]
+	if total <= 0 || len(entry.costs) != 3 {
+		t.Fatalf("unexpected costs %#v", entry.costs)
+	}
+	expected := map[string]float64{"a1": 0.75 * 0.25, "a2": 0.75 * 0.75, "b1": 0.25}
+	for id, share := range expected {
+		if math.Abs(entry.costs[id]/total-share) > 1e-9 {
+			t.Errorf("unexpected share of %v: %v", id, entry.costs[id]/total)
+		}
+	}
+	if entry.owners["a2"] != "a" || entry.owners["b1"] != "b" || entry.tasks != nil {
+		t.Errorf("unexpected entry %#v", entry)
+	}
+}
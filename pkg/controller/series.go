@@ -0,0 +1,374 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	permissions "github.com/SENERGY-Platform/permission-search/lib/client"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prometheus_model "github.com/prometheus/common/model"
+)
+
+const maxSeriesSteps = 11000 // prometheus rejects range queries with more points
+
+// ErrInvalidSeriesRequest is returned, if the parameters of a series request are invalid
+var ErrInvalidSeriesRequest = errors.New("invalid series request")
+
+// seriesQuery collects the results of range queries as the cost of every step by group
+type seriesQuery struct {
+	c             *Controller
+	start         time.Time
+	end           time.Time
+	step          time.Duration
+	pricingModels model.PricingModelVersions
+	points        map[int64]map[string]model.CostEntry // unix timestamp -> group -> cost
+}
+
+// add runs the range query and adds the cost of each sample to the group of its series. The cost is calculated with
+// the pricing model, which is valid at the beginning of the step.
+func (q *seriesQuery) add(query string, group func(metric prometheus_model.Metric) string, cost func(price model.PricingModel, metric prometheus_model.Metric, value float64) model.CostEntry) error {
+	resp, w, err := q.c.prometheus.QueryRange(context.Background(), query, v1.Range{Start: q.start.Add(q.step), End: q.end, Step: q.step})
+	if err != nil {
+		return err
+	}
+	if len(w) > 0 {
+		log.Printf("WARNING: prometheus warnings = %#v\n", w)
+	}
+	matrix, ok := resp.(prometheus_model.Matrix)
+	if !ok {
+		return fmt.Errorf("unexpected prometheus response %#v", resp)
+	}
+	for _, stream := range matrix {
+		g := group(stream.Metric)
+		for _, sample := range stream.Values {
+			ts := sample.Timestamp.Time()
+			groups, ok := q.points[ts.Unix()]
+			if !ok {
+				groups = map[string]model.CostEntry{}
+				q.points[ts.Unix()] = groups
+			}
+			entry := groups[g]
+			entry.Add(cost(q.pricingModels.At(ts.Add(-q.step)), stream.Metric, sampleToFloat(sample.Value)))
+			groups[g] = entry
+		}
+	}
+	return nil
+}
+
+// addPods adds the cpu, ram, network and optionally storage cost of the pods matching the filter, scaled by factor.
+// Series are aggregated by the labels in by, which have to contain all labels used by group.
+func (q *seriesQuery) addPods(f filter, costType model.CostType, by string, group func(metric prometheus_model.Metric) string, factor float64, storage bool) error {
+	stepStr := q.step.String()
+	hours := q.step.Hours()
+
+	series, join := q.c.cpuQueryParts(&f, costType)
+	err := q.add("sum by ("+by+") (avg_over_time("+series+"["+stepStr+":])"+join+")", group, func(price model.PricingModel, metric prometheus_model.Metric, value float64) model.CostEntry {
+		value *= factor
+		return model.CostEntry{
+			Cpu:      model.ToDecimal(value * hours * price.CPUPrice(string(metric["namespace"]), costType)),
+			CpuHours: value * hours,
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	series, join = q.c.ramQueryParts(&f, costType)
+	err = q.add("sum by ("+by+") (avg_over_time("+series+"["+stepStr+":])"+join+")", group, func(price model.PricingModel, metric prometheus_model.Metric, value float64) model.CostEntry {
+		value *= factor
+		return model.CostEntry{
+			Ram:        model.ToDecimal(value * hours / 1000000000 * price.RAMPrice(string(metric["namespace"]), costType)),
+			RamGbHours: value * hours / 1000000000,
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if storage {
+		err = q.add("sum by ("+by+", storageclass) ("+q.c.storageQuery(&f, "["+stepStr+":]")+")", group, func(price model.PricingModel, metric prometheus_model.Metric, value float64) model.CostEntry {
+			value *= factor
+			return model.CostEntry{
+				Storage:        model.ToDecimal(value * hours / 1000000000 * price.StoragePrice(string(metric["namespace"]), costType, string(metric["storageclass"]))),
+				StorageGbHours: value * hours / 1000000000,
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	selector, join := q.c.networkQueryParts(&f)
+	for _, direction := range networkDirections {
+		err = q.add("sum by ("+by+") (sum by (namespace, pod) (increase("+direction.metric+selector+"["+stepStr+"]))"+join+")", group, func(price model.PricingModel, metric prometheus_model.Metric, value float64) model.CostEntry {
+			value *= factor
+			return model.CostEntry{
+				Network:   model.ToDecimal(value / 1000000000 * direction.price(price)),
+				NetworkGb: value / 1000000000,
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTableStorage adds the storage cost of the timescale tables of the series, which is labeled with the table name
+func (q *seriesQuery) addTableStorage(series string, costType model.CostType, group func(metric prometheus_model.Metric) string) error {
+	hours := q.step.Hours()
+	return q.add("avg_over_time("+series+"["+q.step.String()+":])", group, func(price model.PricingModel, _ prometheus_model.Metric, value float64) model.CostEntry {
+		return model.CostEntry{
+			Storage:        model.ToDecimal(value * hours / 1000000000 * timescaleStoragePrice(costType)(price)),
+			StorageGbHours: value * hours / 1000000000,
+		}
+	})
+}
+
+// labelGroup groups series by the value of the label
+func labelGroup(label string) func(metric prometheus_model.Metric) string {
+	return func(metric prometheus_model.Metric) string {
+		return string(metric[prometheus_model.LabelName(label)])
+	}
+}
+
+// GetSeries returns the cost of every step between start and end. Steps are aligned to start and each step
+// uses the pricing model, which is valid at its beginning. Children are the nodes below the root of the cost tree
+// of the cost type. The user specific fractions of shared process components are calculated once for the whole
+// time range.
+func (c *Controller) GetSeries(userId string, token string, admin bool, costType model.CostType, startPtr *time.Time, endPtr *time.Time, step time.Duration, withChildren bool) (result model.CostSeries, err error) {
+	timer := time.Now()
+	if (startPtr == nil) != (endPtr == nil) {
+		return result, fmt.Errorf("%w: must not provide only one of start or end", ErrInvalidSeriesRequest)
+	}
+	if startPtr == nil {
+		startPtr, endPtr = c.defaultStartEnd()
+	}
+	start, end := *startPtr, *endPtr
+	if !slices.Contains(treeCostTypes, costType) {
+		return result, fmt.Errorf("%w: unknown cost type %v", ErrInvalidSeriesRequest, costType)
+	}
+	if step < time.Minute {
+		return result, fmt.Errorf("%w: step must be at least 1m", ErrInvalidSeriesRequest)
+	}
+	if end.Before(start.Add(step)) {
+		return result, fmt.Errorf("%w: time range must contain at least one step", ErrInvalidSeriesRequest)
+	}
+	if end.Sub(start)/step > maxSeriesSteps {
+		return result, fmt.Errorf("%w: time range must not contain more than %v steps", ErrInvalidSeriesRequest, maxSeriesSteps)
+	}
+
+	q := &seriesQuery{
+		c:             c,
+		start:         start,
+		end:           end,
+		step:          step,
+		pricingModels: c.getPricingModels(),
+		points:        map[int64]map[string]model.CostEntry{},
+	}
+	switch costType {
+	case model.CostTypeAnalytics:
+		f := filter{Namespace: &c.config.NamespaceAnalytics, Labels: map[string][]string{"label_user": {userId}}}
+		err = q.addPods(f, costType, "namespace, label_pipeline_id", labelGroup("label_pipeline_id"), 1, true)
+	case model.CostTypeImports:
+		f := filter{Namespace: &c.config.NamespaceImports, Labels: map[string][]string{"label_user": {userId}}}
+		err = q.addPods(f, costType, "namespace, label_import_id", labelGroup("label_import_id"), 1, false)
+	case model.CostTypeProcesses:
+		err = c.addProcessSeries(q, userId)
+	case model.CostTypeApiCalls:
+		err = c.addApiCallsSeries(q, userId)
+	case model.CostTypeDevices:
+		err = c.addDevicesSeries(q, userId, token)
+	case model.CostTypeExports:
+		err = c.addExportsSeries(q, userId, token, admin)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	result = model.CostSeries{
+		Values:   []model.CostSeriesEntry{},
+		Currency: q.pricingModels.At(end).Currency,
+	}
+	if withChildren {
+		result.Children = map[string][]model.CostSeriesEntry{}
+	}
+	for ts := start.Add(step); !ts.After(end); ts = ts.Add(step) {
+		root := model.CostEntry{}
+		for group, entry := range q.points[ts.Unix()] {
+			root.Add(entry)
+			if withChildren {
+				result.Children[group] = append(result.Children[group], model.CostSeriesEntry{Time: ts, CostEntry: entry.Round(c.rounding)})
+			}
+		}
+		result.Values = append(result.Values, model.CostSeriesEntry{Time: ts, CostEntry: root.Round(c.rounding)})
+	}
+	c.logDebug("Series " + time.Since(timer).String())
+	return result, nil
+}
+
+// addProcessSeries adds the share of the user in the process cost sources like GetProcessTree
+func (c *Controller) addProcessSeries(q *seriesQuery, userId string) error {
+	userProcessFactor, err := c.getUserProcessFactor(userId, q.start, q.end)
+	if err != nil {
+		return err
+	}
+	if userProcessFactor > 0 {
+		for k, v := range c.config.ProcessCostSources {
+			f := filter{Namespace: &k, Labels: map[string][]string{"pod": v}}
+			err = q.addPods(f, model.CostTypeProcesses, "namespace, pod", func(metric prometheus_model.Metric) string {
+				return processName(string(metric["pod"]))
+			}, userProcessFactor, true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	userMarshallerFactor, err := c.getUserMarshallerFactor(userId, q.start, q.end)
+	if err != nil {
+		return err
+	}
+	if userMarshallerFactor > 0 {
+		processMarshallerFactor, err := c.getProcessMarshallerFactor(q.start, q.end)
+		if err != nil {
+			return err
+		}
+		for k, v := range c.config.MarshallingCostSources {
+			f := filter{Namespace: &k, Labels: map[string][]string{"pod": v}}
+			err = q.addPods(f, model.CostTypeProcesses, "namespace", func(prometheus_model.Metric) string {
+				return "marshalling"
+			}, processMarshallerFactor*userMarshallerFactor, true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	userProcessIoFactor, err := c.getUserProcessIoFactor(userId, q.start, q.end)
+	if err != nil {
+		return err
+	}
+	if userProcessIoFactor != 0 {
+		for k, v := range c.config.ProcessIoCostSources {
+			f := filter{Namespace: &k, Labels: map[string][]string{"pod": v}}
+			err = q.addPods(f, model.CostTypeProcesses, "namespace", func(prometheus_model.Metric) string {
+				return "process-io"
+			}, userProcessIoFactor, true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addApiCallsSeries adds the requests of the clients of the user like GetApiCallsTree
+func (c *Controller) addApiCallsSeries(q *seriesQuery, userId string) error {
+	username, err := c.getUsername(userId)
+	if err != nil {
+		return err
+	}
+	clientPrefix := username + "_"
+	selector := "kong_http_requests_total{consumer=~\"" + clientPrefix + ".*\"}"
+	return q.add("sum by (exported_service, consumer) (increase("+selector+"["+q.step.String()+"]))", func(metric prometheus_model.Metric) string {
+		return strings.TrimPrefix(string(metric["consumer"]), clientPrefix)
+	}, func(price model.PricingModel, metric prometheus_model.Metric, value float64) model.CostEntry {
+		return model.CostEntry{
+			Requests:     value,
+			RequestsCost: model.ToDecimal(value / 1000 * price.ApiCallPrice(string(metric["exported_service"]), string(metric["consumer"]))),
+		}
+	})
+}
+
+// addDevicesSeries adds the storage and message cost of the devices of the user like GetDevicesTree
+func (c *Controller) addDevicesSeries(q *seriesQuery, userId string, token string) error {
+	deviceGroup := func(metric prometheus_model.Metric) string {
+		matches := deviceTableMatch.FindStringSubmatch(string(metric["table"]))
+		if len(matches) != 3 {
+			return ""
+		}
+		id, err := models.LongId(matches[1])
+		if err != nil {
+			return ""
+		}
+		return deviceIdPrefix + id
+	}
+	limit := 5000
+	var after *permissions.ListAfter
+	for {
+		deviceIds, tables, err := c.queryDevicePage(token, permissions.ConditionConfig{
+			Feature:   "features.owner_id",
+			Value:     userId,
+			Operation: permissions.QueryEqualOperation,
+		}, limit, after)
+		if err != nil {
+			return err
+		}
+		if len(deviceIds) == 0 {
+			return nil
+		}
+		err = q.addTableStorage("table:timescale_table_size_bytes:avg_1h{table=~\""+strings.Join(tables, "|")+"\"}", model.CostTypeDevices, deviceGroup)
+		if err != nil {
+			return err
+		}
+		err = q.add(c.deviceMessagesQuery(deviceIds, q.step), labelGroup("device_id"), func(price model.PricingModel, metric prometheus_model.Metric, value float64) model.CostEntry {
+			return model.CostEntry{
+				Requests:     value,
+				RequestsCost: model.ToDecimal(value / 1000 * price.DeviceMessagePrice(c.deviceMessageType(metric))),
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if len(deviceIds) < limit {
+			return nil
+		}
+		after = &permissions.ListAfter{Id: deviceIds[len(deviceIds)-1]}
+	}
+}
+
+// addExportsSeries adds the storage cost of the exports of the user like GetExportsTree
+func (c *Controller) addExportsSeries(q *seriesQuery, userId string, token string, admin bool) error {
+	tables, err := c.exportTables(userId, token, admin)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	return q.addTableStorage("avg by (table) (timescale_table_size_bytes{table=~\""+strings.Join(tables, "|")+"\"})", model.CostTypeExports, func(metric prometheus_model.Metric) string {
+		matches := exportTableMatch.FindStringSubmatch(string(metric["table"]))
+		if len(matches) != 3 {
+			return ""
+		}
+		id, err := models.LongId(matches[2])
+		if err != nil {
+			return ""
+		}
+		return id
+	})
+}
@@ -0,0 +1,87 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/configuration"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prometheus_model "github.com/prometheus/common/model"
+)
+
+func TestGetSeries(t *testing.T) {
+	prometheus := &fakePrometheus{respondRange: func(query string, r v1.Range) prometheus_model.Matrix {
+		if !strings.Contains(query, "container_cpu_usage_seconds_total") {
+			return prometheus_model.Matrix{}
+		}
+		// 2 cores in every step
+		stream := &prometheus_model.SampleStream{Metric: prometheus_model.Metric{"namespace": "analytics", "label_pipeline_id": "pipeline"}}
+		for ts := r.Start; !ts.After(r.End); ts = ts.Add(r.Step) {
+			stream.Values = append(stream.Values, prometheus_model.SamplePair{Timestamp: prometheus_model.TimeFromUnix(ts.Unix()), Value: 2})
+		}
+		return prometheus_model.Matrix{stream}
+	}}
+	c := &Controller{prometheus: prometheus, config: &configuration.ConfigStruct{NamespaceAnalytics: "analytics"}}
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(4 * time.Hour)
+	c.setPricingModels(model.PricingModelVersions{{CPU: 1}, {ValidFrom: start.Add(2 * time.Hour), CPU: 2}})
+
+	series, err := c.GetSeries("user", "", false, model.CostTypeAnalytics, &start, &end, time.Hour, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prometheus.ranges) == 0 {
+		t.Fatal("no range query")
+	}
+	for i, r := range prometheus.ranges {
+		if !r.Start.Equal(start.Add(time.Hour)) || !r.End.Equal(end) || r.Step != time.Hour {
+			t.Errorf("unexpected range %#v for %v", r, prometheus.queries[i])
+		}
+	}
+	if !strings.Contains(prometheus.queries[0], "[1h0m0s:]") {
+		t.Errorf("unexpected query %v", prometheus.queries[0])
+	}
+
+	// each step is priced with the pricing model valid at its beginning
+	expected := []float64{2, 2, 4, 4}
+	if len(series.Values) != len(expected) || len(series.Children["pipeline"]) != len(expected) {
+		t.Fatalf("unexpected series %#v", series)
+	}
+	for i, cost := range expected {
+		entry := series.Values[i]
+		if !entry.Time.Equal(start.Add(time.Duration(i+1)*time.Hour)) || !entry.Cpu.Equal(model.ToDecimal(cost)) || entry.CpuHours != 2 {
+			t.Errorf("unexpected entry %v %#v", i, entry)
+		}
+		if !series.Children["pipeline"][i].Cpu.Equal(entry.Cpu) {
+			t.Errorf("unexpected child entry %v %#v", i, series.Children["pipeline"][i])
+		}
+	}
+
+	_, err = c.GetSeries("user", "", false, model.CostTypeAnalytics, &start, &end, time.Second, false)
+	if !errors.Is(err, ErrInvalidSeriesRequest) {
+		t.Errorf("expected invalid step, got %v", err)
+	}
+	_, err = c.GetSeries("user", "", false, "unknown", &start, &end, time.Hour, false)
+	if !errors.Is(err, ErrInvalidSeriesRequest) {
+		t.Errorf("expected invalid cost type, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+// runSnapshots closes the past billing periods immediately and then checks every interval, if another month has to be closed
+func (c *Controller) runSnapshots(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := c.closeMonths(time.Now())
+		if err != nil {
+			log.Println("ERROR: unable to close months, retry in", interval, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// closeMonths closes all billing periods, which ended after the last closed month
+func (c *Controller) closeMonths(now time.Time) error {
+	starts, err := c.unclosedPeriods(now)
+	if err != nil {
+		return err
+	}
+	for _, start := range starts {
+		err = c.closeMonth(start, c.billing.PeriodEnd(start))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unclosedPeriods returns the starts of all billing periods, which ended after the last closed month.
+// If no month has been closed yet, only the last period is returned.
+func (c *Controller) unclosedPeriods(now time.Time) (starts []time.Time, err error) {
+	start := c.billing.PeriodStart(c.billing.PeriodStart(now).Add(-time.Nanosecond))
+	lastClosed, found, err := c.db.LastClosedMonth()
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		_, start, err = c.billing.Period(lastClosed)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for ; !c.billing.PeriodEnd(start).After(now); start = c.billing.PeriodEnd(start) {
+		starts = append(starts, start)
+	}
+	return starts, nil
+}
+
+// closeMonth stores the cost trees of all users for the billing period, if it has not been closed yet.
+// Users, which fail, are logged and skipped. Their cost is calculated on request, since they have no snapshot.
+func (c *Controller) closeMonth(start time.Time, end time.Time) error {
+	month := c.billing.PeriodName(start)
+	closed, err := c.db.IsMonthClosed(month)
+	if err != nil || closed {
+		return err
+	}
+	if c.tokenProvider == nil {
+		return fmt.Errorf("missing auth_endpoint config")
+	}
+	token, err := c.tokenProvider()
+	if err != nil {
+		return err
+	}
+	users, err := c.getUsers(token, start, end)
+	if err != nil {
+		return err
+	}
+	log.Println("close month", month, "for", len(users), "users")
+	for userId := range users {
+		_, found, err := c.db.GetSnapshot(month, userId)
+		if err != nil {
+			return err
+		}
+		if found {
+			continue
+		}
+		tree, err := c.getCostTreeWithTiers(userId, token, true, true, model.EstimationOptions{}, &start, &end)
+		if err != nil {
+			log.Println("ERROR: unable to calculate cost tree of", userId, "for month", month, err)
+			continue
+		}
+		err = c.db.SetSnapshot(month, userId, tree)
+		if err != nil {
+			return err
+		}
+	}
+	return c.db.SetMonthClosed(month)
+}
+
+type snapshotPart struct {
+	start    time.Time
+	end      time.Time
+	snapshot model.CostTree // nil if the part has to be calculated
+}
+
+// getCostTreeByPeriods splits the time range at billing period boundaries and uses the snapshots of all closed months,
+// which are fully covered by the time range. All other parts are calculated separately with calculate, so that long
+// time ranges do not exceed the limits of prometheus subqueries. If estimate is set, only the last part is calculated
+// with estimation and the estimations of all other parts are their measured cost.
+// Returns false, if the time range is within a single billing period without snapshot.
+func (c *Controller) getCostTreeByPeriods(userId string, start time.Time, end time.Time, estimate bool, calculate func(start time.Time, end time.Time, estimate bool) (model.CostTree, error)) (tree model.CostTree, ok bool, err error) {
+	parts := []snapshotPart{}
+	for partStart := start; partStart.Before(end); {
+		monthStart := c.billing.PeriodStart(partStart)
+		monthEnd := c.billing.PeriodEnd(partStart)
+		partEnd := monthEnd
+		if end.Before(partEnd) {
+			partEnd = end
+		}
+		var snapshot model.CostTree
+		if partStart.Equal(monthStart) && partEnd.Equal(monthEnd) {
+			snapshot, err = c.getSnapshot(c.billing.PeriodName(monthStart), userId)
+			if err != nil {
+				return tree, false, err
+			}
+		}
+		parts = append(parts, snapshotPart{start: partStart, end: partEnd, snapshot: snapshot})
+		partStart = partEnd
+	}
+	ok = len(parts) > 1 || (len(parts) == 1 && parts[0].snapshot != nil)
+	if !ok {
+		return tree, false, nil
+	}
+	tree = model.CostTree{}
+	for i, part := range parts {
+		projected := false
+		if part.snapshot == nil {
+			projected = estimate && i == len(parts)-1
+			part.snapshot, err = calculate(part.start, part.end, projected)
+			if err != nil {
+				return tree, true, err
+			}
+		}
+		if estimate && !projected {
+			part.snapshot = part.snapshot.AsEstimation()
+		}
+		tree = tree.Merge(part.snapshot)
+	}
+	return tree, true, nil
+}
+
+// getSnapshot returns the snapshot of the user, if the month is closed and the snapshot exists.
+// Returns nil, if the cost of the month has to be calculated.
+func (c *Controller) getSnapshot(month string, userId string) (model.CostTree, error) {
+	closed, err := c.db.IsMonthClosed(month)
+	if err != nil || !closed {
+		return nil, err
+	}
+	tree, found, err := c.db.GetSnapshot(month, userId)
+	if err != nil || !found {
+		return nil, err
+	}
+	return tree, nil
+}
@@ -0,0 +1,113 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/database"
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func TestUnclosedPeriods(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	c := &Controller{db: db, billing: model.BillingCalendar{AnchorDay: 15}}
+	now := time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)
+
+	starts, err := c.unclosedPeriods(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(starts) != 1 || !starts[0].Equal(time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected only the last period without closed months, got %v", starts)
+	}
+
+	// months missed while the service was down are closed, too
+	err = db.SetMonthClosed("2024-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	starts, err = c.unclosedPeriods(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []time.Time{
+		time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC),
+	}
+	if len(starts) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, starts)
+	}
+	for i := range expected {
+		if !starts[i].Equal(expected[i]) {
+			t.Fatalf("expected %v, got %v", expected, starts)
+		}
+	}
+
+	err = db.SetMonthClosed("2024-04")
+	if err != nil {
+		t.Fatal(err)
+	}
+	starts, err = c.unclosedPeriods(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(starts) != 0 {
+		t.Fatalf("expected no unclosed periods, got %v", starts)
+	}
+}
+
+func TestGetCostTreeByPeriodsWithEstimation(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	c := &Controller{db: db}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	estimated := []time.Time{}
+	tree, ok, err := c.getCostTreeByPeriods("user", start, end, true, func(start time.Time, end time.Time, estimate bool) (model.CostTree, error) {
+		node := model.CostWithChildren{CostWithEstimation: model.CostWithEstimation{Month: model.CostEntry{Cpu: model.ToDecimal(1)}}}
+		if estimate {
+			estimated = append(estimated, start)
+			node.EstimationMonth = model.CostEntry{Cpu: model.ToDecimal(3)}
+		}
+		return model.CostTree{model.CostTypeAnalytics: node}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected the time range to be split")
+	}
+	if len(estimated) != 1 || !estimated[0].Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected only the last period to be projected, got %v", estimated)
+	}
+	node := tree[model.CostTypeAnalytics]
+	if !node.Month.Cpu.Equal(model.ToDecimal(3)) || !node.EstimationMonth.Cpu.Equal(model.ToDecimal(5)) {
+		t.Errorf("expected the measured periods in the estimation, got %#v", node.CostWithEstimation)
+	}
+}
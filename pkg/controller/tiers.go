@@ -0,0 +1,120 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	"github.com/shopspring/decimal"
+)
+
+// getCostTreeWithTiers calculates the cost tree of a time range within a single billing period and applies the tiers
+// of the pricing model. Usage of the billing period before start counts against the free allowance and the tiers, so
+// that each billing period is granted its allowance only once, even if it is calculated in several parts.
+func (c *Controller) getCostTreeWithTiers(userid string, token string, admin bool, skipEstimation bool, estimation model.EstimationOptions, start *time.Time, end *time.Time) (res model.CostTree, err error) {
+	res, err = c.getCostTree(userid, token, admin, skipEstimation, estimation, start, end)
+	if err != nil {
+		return res, err
+	}
+	ts := time.Now()
+	if end != nil {
+		ts = *end
+	}
+	if len(c.getPricingModels().At(ts).Tiers) > 0 {
+		from := c.billing.PeriodStart(ts)
+		if start != nil {
+			from = *start
+		}
+		var before model.CostTree
+		periodStart := c.billing.PeriodStart(from)
+		if from.After(periodStart) {
+			before, err = c.getCostTree(userid, token, admin, true, model.EstimationOptions{}, &periodStart, &from)
+			if err != nil {
+				return res, err
+			}
+		}
+		c.applyTiers(res, before, skipEstimation, ts)
+	}
+	for k, v := range res {
+		res[k] = c.finalize(v, ts)
+	}
+	return res, nil
+}
+
+// applyTiers evaluates the tiers of the pricing model valid at ts against the quantities of the whole tree.
+// All other costs are calculated with the flat prices and overrides, so the difference between the tiered cost and the
+// charged cost is added as a separate node, which also lists the tier each quantity fell into. Quantities exceeding
+// the last tier keep their charged cost. before contains the usage of the billing period before the tree, which has
+// already used up parts of the tiers, and may be nil. The listed tier usages refer to the billing period up to the
+// end of the tree.
+func (c *Controller) applyTiers(tree model.CostTree, before model.CostTree, skipEstimation bool, ts time.Time) {
+	pricingModel := c.getPricingModels().At(ts)
+	if len(pricingModel.Tiers) == 0 {
+		return
+	}
+	sum := func(tree model.CostTree) model.CostWithEstimation {
+		total := model.CostWithEstimation{}
+		for k, v := range tree {
+			if k == model.CostTypeOverhead || k == model.CostTypeTiers {
+				continue // overhead is not used by the user, so it may not use up free allowances
+			}
+			total.Add(v.CostWithEstimation)
+		}
+		return total
+	}
+	total := sum(tree)
+	previous := sum(before).Month
+
+	node := model.CostWithChildren{
+		Tiers: map[model.TierDimension]model.TierUsages{},
+	}
+	for dimension, tieredPrice := range pricingModel.Tiers {
+		// returns the adjustment of the cost charged for the entry and the tier usages of the billing period
+		adjust := func(entry model.CostEntry) (decimal.Decimal, []model.TierUsage) {
+			quantity := entry.Quantity(dimension)
+			charged := entry.Cost(dimension)
+			previousQuantity := previous.Quantity(dimension)
+			flatPrice := pricingModel.FlatPrice(dimension)
+			if quantity+previousQuantity != 0 {
+				flatPrice = charged.Add(previous.Cost(dimension)).InexactFloat64() / (quantity + previousQuantity)
+			}
+			cost, usages := tieredPrice.Apply(previousQuantity+quantity, flatPrice)
+			previousCost, _ := tieredPrice.Apply(previousQuantity, flatPrice)
+			return model.ToDecimal(cost - previousCost).Sub(charged), usages
+		}
+		usages := model.TierUsages{}
+
+		adjustment, monthUsages := adjust(total.Month)
+		usages.Month = monthUsages
+		node.Month.AddCost(dimension, adjustment)
+
+		if !skipEstimation {
+			adjustment, estimationUsages := adjust(total.EstimationMonth)
+			usages.EstimationMonth = estimationUsages
+			node.EstimationMonth.AddCost(dimension, adjustment)
+
+			adjustment, _ = adjust(total.EstimationMonthLower)
+			node.EstimationMonthLower.AddCost(dimension, adjustment)
+
+			adjustment, _ = adjust(total.EstimationMonthUpper)
+			node.EstimationMonthUpper.AddCost(dimension, adjustment)
+		}
+		node.Tiers[dimension] = usages
+	}
+	tree[model.CostTypeTiers] = node
+}
@@ -0,0 +1,56 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func TestApplyTiers(t *testing.T) {
+	c := &Controller{}
+	c.setPricingModels(model.PricingModelVersions{{
+		CPU: 1,
+		Tiers: map[model.TierDimension]model.TieredPrice{
+			model.TierDimensionCpu: {Free: 10},
+		},
+	}})
+	ts := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	// 20 core hours were charged with a namespace override of 2 instead of the flat price of 1
+	tree := model.CostTree{
+		model.CostTypeAnalytics: {CostWithEstimation: model.CostWithEstimation{Month: model.CostEntry{CpuHours: 20, Cpu: model.ToDecimal(40)}}},
+	}
+	c.applyTiers(tree, nil, true, ts)
+	if cpu := tree[model.CostTypeTiers].Month.Cpu; !cpu.Equal(model.ToDecimal(-20)) {
+		t.Errorf("expected the free allowance to remove the charged cost of 10 core hours, got %v", cpu)
+	}
+
+	// the allowance was already used up earlier in the billing period
+	tree = model.CostTree{
+		model.CostTypeAnalytics: {CostWithEstimation: model.CostWithEstimation{Month: model.CostEntry{CpuHours: 5, Cpu: model.ToDecimal(5)}}},
+	}
+	before := model.CostTree{
+		model.CostTypeAnalytics: {CostWithEstimation: model.CostWithEstimation{Month: model.CostEntry{CpuHours: 10, Cpu: model.ToDecimal(10)}}},
+	}
+	c.applyTiers(tree, before, true, ts)
+	if cpu := tree[model.CostTypeTiers].Month.Cpu; !cpu.IsZero() {
+		t.Errorf("expected no allowance for the rest of the billing period, got %v", cpu)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"log"
+	"time"
+
+	serving "github.com/SENERGY-Platform/analytics-serving/client"
+	permissions "github.com/SENERGY-Platform/permission-search/lib/client"
+)
+
+// getUsers returns the ids of all users, which may have cost between start and end: users returned by the
+// snapshot_users_queries, owners of export instances and owners of devices. Users, which only have API calls,
+// can not be found by their username and are not returned.
+func (c *Controller) getUsers(token string, start time.Time, end time.Time) (users map[string]bool, err error) {
+	users, err = c.getPrometheusUsers(start, end)
+	if err != nil {
+		return users, err
+	}
+
+	t := true
+	instances, err := c.servingClient.ListInstancesAsAdmin(token, &serving.ListOptions{InternalOnly: &t})
+	if err != nil {
+		return users, err
+	}
+	for _, instance := range instances {
+		users[instance.UserId] = true
+	}
+
+	limit := 5000
+	var after *permissions.ListAfter
+	for {
+		devices, err := c.queryDevices(token, nil, limit, after)
+		if err != nil {
+			return users, err
+		}
+		for _, device := range devices {
+			owner, ok := device["owner_id"].(string)
+			if !ok {
+				log.Println("WARNING: device without owner_id", device["id"])
+				continue
+			}
+			users[owner] = true
+		}
+		if len(devices) < limit {
+			break
+		}
+		deviceId, _ := devices[len(devices)-1]["id"].(string)
+		after = &permissions.ListAfter{Id: deviceId}
+	}
+	delete(users, "")
+	return users, nil
+}
+
+// getPrometheusUsers returns the ids of all users returned by the snapshot_users_queries
+func (c *Controller) getPrometheusUsers(start time.Time, end time.Time) (users map[string]bool, err error) {
+	users = map[string]bool{}
+	for _, query := range c.config.SnapshotUsersQueries {
+		values, err := c.getValueMapFromPrometheus(query, "", start, end)
+		if err != nil {
+			return users, err
+		}
+		for userId := range values {
+			users[userId] = true
+		}
+	}
+	delete(users, "")
+	return users, nil
+}
@@ -17,8 +17,11 @@
 package controller
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"slices"
 	"time"
@@ -66,14 +69,16 @@ func (c *Controller) getUsername(userId string) (username string, err error) {
 }
 
 type costWithChildrenAndStats struct {
-	stats    []stat
-	children map[string]costWithChildrenAndStats
+	stats        []stat
+	children     map[string]costWithChildrenAndStats
+	billingBasis model.BillingMode
 	model.CostWithEstimation
 }
 
 func (c *costWithChildrenAndStats) toModelCostWithChildrenAndStats() (m model.CostWithChildren) {
 	m = model.CostWithChildren{
 		CostWithEstimation: c.CostWithEstimation,
+		BillingBasis:       c.billingBasis,
 		Children:           map[string]model.CostWithChildren{},
 	}
 	for k, v := range c.children {
@@ -100,6 +105,9 @@ func _buildTree(stats []stat, labels ...string) (tree costWithChildrenAndStats)
 			EstimationMonth: model.CostEntry{},
 		},
 	}
+	for _, s := range stats {
+		tree.billingBasis = model.CombineBillingBasis(tree.billingBasis, s.BillingBasis)
+	}
 	if len(labels) == 0 {
 		for _, child := range stats {
 			tree.CostWithEstimation.Add(child.CostWithEstimation)
@@ -131,8 +139,62 @@ func _buildTree(stats []stat, labels ...string) (tree costWithChildrenAndStats)
 	return tree
 }
 
-func defaultStartEnd() (start *time.Time, end *time.Time) {
+// GetBillingCalendar returns the configured billing calendar, which is used to resolve periods and dates
+func (c *Controller) GetBillingCalendar() model.BillingCalendar {
+	return c.billing
+}
+
+// defaultStartEnd returns the start of the current billing period and now
+func (c *Controller) defaultStartEnd() (start *time.Time, end *time.Time) {
 	e := time.Now()
-	s := time.Date(e.Year(), e.Month(), 0, 0, 0, 0, 0, time.UTC)
+	s := c.billing.PeriodStart(e)
 	return &s, &e
 }
+
+// timescaleStoragePrice returns a function to select the price of timescale tables of the cost type
+func timescaleStoragePrice(costType model.CostType) func(m model.PricingModel) float64 {
+	return func(m model.PricingModel) float64 {
+		return m.StoragePrice("", costType, "")
+	}
+}
+
+func deviceMessagePrice(messageType string) func(m model.PricingModel) float64 {
+	return func(m model.PricingModel) float64 {
+		return m.DeviceMessagePrice(messageType)
+	}
+}
+
+// addEstimation adds the entries to the estimation and its 10th and 90th percentile
+func addEstimation(node *model.CostWithEstimation, lower model.CostEntry, estimation model.CostEntry, upper model.CostEntry) {
+	node.EstimationMonthLower.Add(lower)
+	node.EstimationMonth.Add(estimation)
+	node.EstimationMonthUpper.Add(upper)
+}
+
+// averagePrice returns the time weighted average of the prices between start and end
+func (c *Controller) averagePrice(start time.Time, end time.Time, price func(m model.PricingModel) float64) float64 {
+	pricingModels := c.getPricingModels()
+	hours := end.Sub(start).Hours()
+	if hours <= 0 {
+		return price(pricingModels.At(start))
+	}
+	return pricingModels.PriceHours(start, end, price) / hours
+}
+
+// postWebhook sends the payload as json to the url
+func postWebhook(url string, payload any) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.ReadAll(resp.Body) //ensure resp.Body is read to EOF
+	if resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected statuscode %v", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	prometheus_model "github.com/prometheus/common/model"
+)
+
+func TestBuildTreeBillingBasis(t *testing.T) {
+	stats := []stat{
+		{Labels: prometheus_model.Metric{"namespace": "a", "pod": "p1", "container": "c1"}, BillingBasis: model.BillingModeUsage},
+		{Labels: prometheus_model.Metric{"namespace": "a", "pod": "p1"}}, // storage has no billing basis
+		{Labels: prometheus_model.Metric{"namespace": "b", "pod": "p2", "container": "c2"}, BillingBasis: model.BillingModeRequests},
+	}
+	tree := buildTree(stats, "namespace", "pod")
+	if tree.BillingBasis != model.BillingBasisMixed {
+		t.Fatalf("expected mixed billing basis at root, got %q", tree.BillingBasis)
+	}
+	if basis := tree.Children["a"].Children["p1"].BillingBasis; basis != model.BillingModeUsage {
+		t.Fatalf("expected usage billing basis for pod p1, got %q", basis)
+	}
+	if basis := tree.Children["b"].BillingBasis; basis != model.BillingModeRequests {
+		t.Fatalf("expected requests billing basis for namespace b, got %q", basis)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+var dailyCostsBucket = []byte("daily_costs")
+var anomaliesBucket = []byte("anomalies")
+
+func init() {
+	buckets = append(buckets, dailyCostsBucket, anomaliesBucket)
+}
+
+func dailyCostKey(day string, userId string) []byte {
+	return []byte(day + "/" + userId)
+}
+
+func anomaliesKey(userId string, day string) []byte {
+	return []byte(userId + "/" + day)
+}
+
+// SetDailyCost stores the cost tree of the user for the day
+func (db *Database) SetDailyCost(day string, userId string, tree model.CostTree) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(dailyCostsBucket), dailyCostKey(day, userId), tree)
+	})
+}
+
+// GetDailyCost returns the stored cost tree of the user for the day
+func (db *Database) GetDailyCost(day string, userId string) (tree model.CostTree, found bool, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dailyCostsBucket).Get(dailyCostKey(day, userId))
+		if b == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(b, &tree)
+	})
+	return tree, found, err
+}
+
+// SetAnomalies stores the detected anomalies of the user for the day. An empty list marks the day as checked.
+func (db *Database) SetAnomalies(userId string, day string, anomalies []model.CostAnomaly) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(anomaliesBucket), anomaliesKey(userId, day), anomalies)
+	})
+}
+
+func (db *Database) AnomaliesChecked(userId string, day string) (checked bool, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		checked = tx.Bucket(anomaliesBucket).Get(anomaliesKey(userId, day)) != nil
+		return nil
+	})
+	return checked, err
+}
+
+// ListAnomalies returns all anomalies of the user, ordered by day
+func (db *Database) ListAnomalies(userId string) (result []model.CostAnomaly, err error) {
+	result = []model.CostAnomaly{}
+	err = db.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(anomaliesBucket).Cursor()
+		prefix := []byte(userId + "/")
+		for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+			var anomalies []model.CostAnomaly
+			err := json.Unmarshal(v, &anomalies)
+			if err != nil {
+				return err
+			}
+			result = append(result, anomalies...)
+		}
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,115 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+var budgetsBucket = []byte("budgets")
+var budgetAlertsBucket = []byte("budget_alerts")
+
+func init() {
+	buckets = append(buckets, budgetsBucket, budgetAlertsBucket)
+}
+
+func budgetAlertKey(budgetId string, month string, threshold float64) []byte {
+	return []byte(budgetId + "/" + month + "/" + strconv.FormatFloat(threshold, 'f', -1, 64))
+}
+
+func (db *Database) ListBudgets() (result []model.Budget, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		result, err = list[model.Budget](tx.Bucket(budgetsBucket))
+		return err
+	})
+	return result, err
+}
+
+func (db *Database) GetBudget(id string) (budget model.Budget, found bool, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(budgetsBucket).Get([]byte(id))
+		if b == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(b, &budget)
+	})
+	return budget, found, err
+}
+
+func (db *Database) SetBudget(budget model.Budget) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(budgetsBucket), []byte(budget.Id), budget)
+	})
+}
+
+// DeleteBudget removes the budget and all of its alerts
+func (db *Database) DeleteBudget(id string) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(budgetsBucket).Delete([]byte(id))
+		if err != nil {
+			return err
+		}
+		cursor := tx.Bucket(budgetAlertsBucket).Cursor()
+		prefix := []byte(id + "/")
+		for k, _ := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = cursor.Seek(prefix) {
+			err = cursor.Delete()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListBudgetAlerts returns all alerts which have been sent for the budget
+func (db *Database) ListBudgetAlerts(budgetId string) (result []model.BudgetAlert, err error) {
+	result = []model.BudgetAlert{}
+	err = db.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(budgetAlertsBucket).Cursor()
+		prefix := []byte(budgetId + "/")
+		for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+			var alert model.BudgetAlert
+			err := json.Unmarshal(v, &alert)
+			if err != nil {
+				return err
+			}
+			result = append(result, alert)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (db *Database) SetBudgetAlert(alert model.BudgetAlert) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(budgetAlertsBucket), budgetAlertKey(alert.BudgetId, alert.Month, alert.Threshold), alert)
+	})
+}
+
+func (db *Database) IsBudgetAlertSent(budgetId string, month string, threshold float64) (sent bool, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		sent = tx.Bucket(budgetAlertsBucket).Get(budgetAlertKey(budgetId, month, threshold)) != nil
+		return nil
+	})
+	return sent, err
+}
@@ -0,0 +1,77 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type Database struct {
+	db *bolt.DB
+}
+
+func New(filePath string) (*Database, error) {
+	db, err := bolt.Open(filePath, 0600, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range buckets {
+			_, err := tx.CreateBucketIfNotExists(bucket)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Database{db: db}, nil
+}
+
+func (db *Database) Close() error {
+	return db.db.Close()
+}
+
+var buckets = [][]byte{}
+
+func put(bucket *bolt.Bucket, key []byte, value any) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, b)
+}
+
+func list[T any](bucket *bolt.Bucket) (result []T, err error) {
+	result = []T{}
+	err = bucket.ForEach(func(k, v []byte) error {
+		var element T
+		err := json.Unmarshal(v, &element)
+		if err != nil {
+			return err
+		}
+		result = append(result, element)
+		return nil
+	})
+	return result, err
+}
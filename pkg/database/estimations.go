@@ -0,0 +1,96 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+var estimationRecordsBucket = []byte("estimation_records")
+var evaluatedMonthsBucket = []byte("estimation_evaluated_months")
+
+func init() {
+	buckets = append(buckets, estimationRecordsBucket, evaluatedMonthsBucket)
+}
+
+func estimationRecordKey(record model.EstimationRecord) []byte {
+	return []byte(record.Month + "/" + record.UserId + "/" + record.CostType + "/" + record.Timestamp.UTC().Format(time.RFC3339Nano))
+}
+
+// SetEstimationRecord stores the record. Records with the same month, user, cost type and timestamp are replaced.
+func (db *Database) SetEstimationRecord(record model.EstimationRecord) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(estimationRecordsBucket), estimationRecordKey(record), record)
+	})
+}
+
+// ListEstimationRecords returns the records of the month, ordered by user. All records are returned, if month is empty.
+func (db *Database) ListEstimationRecords(month string) (result []model.EstimationRecord, err error) {
+	result = []model.EstimationRecord{}
+	err = db.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(estimationRecordsBucket).Cursor()
+		prefix := []byte{}
+		if month != "" {
+			prefix = []byte(month + "/")
+		}
+		for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+			var record model.EstimationRecord
+			err := json.Unmarshal(v, &record)
+			if err != nil {
+				return err
+			}
+			result = append(result, record)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// ListEstimationRecordMonths returns all months with records in ascending order
+func (db *Database) ListEstimationRecordMonths() (result []string, err error) {
+	result = []string{}
+	err = db.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(estimationRecordsBucket).ForEach(func(k, v []byte) error {
+			month, _, _ := bytes.Cut(k, []byte("/"))
+			if len(result) == 0 || result[len(result)-1] != string(month) {
+				result = append(result, string(month))
+			}
+			return nil
+		})
+	})
+	return result, err
+}
+
+// SetEstimationsEvaluated marks the month as evaluated, after the actual cost has been added to all records
+func (db *Database) SetEstimationsEvaluated(month string) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(evaluatedMonthsBucket), []byte(month), time.Now().UTC())
+	})
+}
+
+func (db *Database) AreEstimationsEvaluated(month string) (evaluated bool, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		evaluated = tx.Bucket(evaluatedMonthsBucket).Get([]byte(month)) != nil
+		return nil
+	})
+	return evaluated, err
+}
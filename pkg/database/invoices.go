@@ -0,0 +1,68 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+var invoicesBucket = []byte("invoices")
+
+func init() {
+	buckets = append(buckets, invoicesBucket)
+}
+
+func invoiceKey(month string, userId string) []byte {
+	return []byte(month + "/" + userId)
+}
+
+// GetInvoice returns the issued invoice of the user for the month
+func (db *Database) GetInvoice(month string, userId string) (invoice model.Invoice, found bool, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(invoicesBucket).Get(invoiceKey(month, userId))
+		if b == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(b, &invoice)
+	})
+	return invoice, found, err
+}
+
+// IssueInvoice assigns the next sequential number to the invoice and stores it.
+// If an invoice has already been issued for the user and month, the existing invoice is returned unchanged.
+func (db *Database) IssueInvoice(invoice model.Invoice) (result model.Invoice, err error) {
+	err = db.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(invoicesBucket)
+		key := invoiceKey(invoice.Month, invoice.UserId)
+		if existing := bucket.Get(key); existing != nil {
+			return json.Unmarshal(existing, &result)
+		}
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		invoice.Number = fmt.Sprintf("%06d", seq)
+		result = invoice
+		return put(bucket, key, invoice)
+	})
+	return result, err
+}
@@ -0,0 +1,162 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+var pricingModelsBucket = []byte("pricing_models")
+var pricingChangesBucket = []byte("pricing_changes")
+var pricingFileBucket = []byte("pricing_file")
+
+var pricingFileHashKey = []byte("hash")
+
+func init() {
+	buckets = append(buckets, pricingModelsBucket, pricingChangesBucket, pricingFileBucket)
+}
+
+// ListPricingModels returns all stored pricing model versions, sorted by ValidFrom
+func (db *Database) ListPricingModels() (versions model.PricingModelVersions, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		versions, err = list[model.PricingModel](tx.Bucket(pricingModelsBucket))
+		return err
+	})
+	return versions, err
+}
+
+// SetPricingModel adds the pricing model version or replaces the stored version with the same ValidFrom.
+// The change is recorded in the pricing history.
+// validate is called with all versions after the change and may abort it by returning an error.
+func (db *Database) SetPricingModel(version model.PricingModel, userId string, username string, validate func(versions model.PricingModelVersions) error) (change model.PricingChange, err error) {
+	err = db.db.Update(func(tx *bolt.Tx) error {
+		models := tx.Bucket(pricingModelsBucket)
+		key := timeKey(version.ValidFrom)
+		change = model.PricingChange{
+			Timestamp: time.Now().UTC(),
+			UserId:    userId,
+			Username:  username,
+			New:       version,
+		}
+		if old := models.Get(key); old != nil {
+			change.Old = &model.PricingModel{}
+			err = json.Unmarshal(old, change.Old)
+			if err != nil {
+				return err
+			}
+		}
+		err = put(models, key, version)
+		if err != nil {
+			return err
+		}
+		versions, err := list[model.PricingModel](models)
+		if err != nil {
+			return err
+		}
+		err = validate(versions)
+		if err != nil {
+			return err
+		}
+		changes := tx.Bucket(pricingChangesBucket)
+		change.Id, err = changes.NextSequence()
+		if err != nil {
+			return err
+		}
+		return put(changes, binary.BigEndian.AppendUint64(nil, change.Id), change)
+	})
+	return change, err
+}
+
+// DeletePricingModel removes the stored version valid from validFrom. The removal is recorded in the pricing history.
+// validate is called with all versions after the change and may abort it by returning an error.
+func (db *Database) DeletePricingModel(validFrom time.Time, userId string, username string, validate func(versions model.PricingModelVersions) error) (change model.PricingChange, err error) {
+	err = db.db.Update(func(tx *bolt.Tx) error {
+		models := tx.Bucket(pricingModelsBucket)
+		key := timeKey(validFrom)
+		old := models.Get(key)
+		if old == nil {
+			return fmt.Errorf("no pricing model version valid from %v", validFrom.Format(time.RFC3339))
+		}
+		change = model.PricingChange{
+			Timestamp: time.Now().UTC(),
+			UserId:    userId,
+			Username:  username,
+			Old:       &model.PricingModel{},
+			New:       model.PricingModel{ValidFrom: validFrom},
+			Deleted:   true,
+		}
+		err = json.Unmarshal(old, change.Old)
+		if err != nil {
+			return err
+		}
+		err = models.Delete(key)
+		if err != nil {
+			return err
+		}
+		versions, err := list[model.PricingModel](models)
+		if err != nil {
+			return err
+		}
+		err = validate(versions)
+		if err != nil {
+			return err
+		}
+		changes := tx.Bucket(pricingChangesBucket)
+		change.Id, err = changes.NextSequence()
+		if err != nil {
+			return err
+		}
+		return put(changes, binary.BigEndian.AppendUint64(nil, change.Id), change)
+	})
+	return change, err
+}
+
+// ListPricingChanges returns the pricing history, oldest change first
+func (db *Database) ListPricingChanges() (changes []model.PricingChange, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		changes, err = list[model.PricingChange](tx.Bucket(pricingChangesBucket))
+		return err
+	})
+	return changes, err
+}
+
+// SetPricingFileHash stores the hash of the pricing model file, which has been imported last
+func (db *Database) SetPricingFileHash(hash string) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pricingFileBucket).Put(pricingFileHashKey, []byte(hash))
+	})
+}
+
+// GetPricingFileHash returns the hash of the pricing model file, which has been imported last, or an empty string
+func (db *Database) GetPricingFileHash() (hash string, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		hash = string(tx.Bucket(pricingFileBucket).Get(pricingFileHashKey))
+		return nil
+	})
+	return hash, err
+}
+
+// timeKey returns a key, which sorts in chronological order
+func timeKey(t time.Time) []byte {
+	return []byte(t.UTC().Format("2006-01-02T15:04:05.000000000Z"))
+}
@@ -0,0 +1,77 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+)
+
+func TestSetPricingModel(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	validate := model.PricingModelVersions.Validate
+
+	_, err = db.SetPricingModel(model.PricingModel{ValidFrom: feb, CPU: 2}, "user", "name", validate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.SetPricingModel(model.PricingModel{ValidFrom: jan, CPU: 1}, "user", "name", validate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	change, err := db.SetPricingModel(model.PricingModel{ValidFrom: feb, CPU: 3}, "admin", "admin-name", validate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.Id != 3 || change.Old == nil || change.Old.CPU != 2 || change.New.CPU != 3 || change.UserId != "admin" {
+		t.Errorf("unexpected change %#v", change)
+	}
+
+	_, err = db.SetPricingModel(model.PricingModel{ValidFrom: feb, CPU: 4}, "admin", "admin-name", func(versions model.PricingModelVersions) error {
+		return errors.New("rejected")
+	})
+	if err == nil {
+		t.Error("expected error")
+	}
+
+	versions, err := db.ListPricingModels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || !versions[0].ValidFrom.Equal(jan) || versions[1].CPU != 3 {
+		t.Errorf("unexpected versions %#v", versions)
+	}
+
+	changes, err := db.ListPricingChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 || changes[0].Old != nil || changes[2].Username != "admin-name" {
+		t.Errorf("unexpected changes %#v", changes)
+	}
+}
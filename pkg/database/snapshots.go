@@ -0,0 +1,84 @@
+/*
+ *    Copyright 2024 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package database
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/SENERGY-Platform/cost-calculator/pkg/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+var snapshotsBucket = []byte("snapshots")
+var closedMonthsBucket = []byte("closed_months")
+
+func init() {
+	buckets = append(buckets, snapshotsBucket, closedMonthsBucket)
+}
+
+func snapshotKey(month string, userId string) []byte {
+	return []byte(month + "/" + userId)
+}
+
+// SetSnapshot stores the cost tree of the user for the month
+func (db *Database) SetSnapshot(month string, userId string, tree model.CostTree) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(snapshotsBucket), snapshotKey(month, userId), tree)
+	})
+}
+
+// GetSnapshot returns the stored cost tree of the user for the month
+func (db *Database) GetSnapshot(month string, userId string) (tree model.CostTree, found bool, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(snapshotsBucket).Get(snapshotKey(month, userId))
+		if b == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(b, &tree)
+	})
+	return tree, found, err
+}
+
+// SetMonthClosed marks the month as closed, after the snapshots of all users have been stored
+func (db *Database) SetMonthClosed(month string) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(closedMonthsBucket), []byte(month), time.Now().UTC())
+	})
+}
+
+func (db *Database) IsMonthClosed(month string) (closed bool, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		closed = tx.Bucket(closedMonthsBucket).Get([]byte(month)) != nil
+		return nil
+	})
+	return closed, err
+}
+
+// LastClosedMonth returns the latest closed month
+func (db *Database) LastClosedMonth() (month string, found bool, err error) {
+	err = db.db.View(func(tx *bolt.Tx) error {
+		k, _ := tx.Bucket(closedMonthsBucket).Cursor().Last()
+		if k != nil {
+			month = string(k)
+			found = true
+		}
+		return nil
+	})
+	return month, found, err
+}
@@ -0,0 +1,90 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EstimationRecord is the month end estimation of a cost type of a user at a point of time during the month
+type EstimationRecord struct {
+	UserId     string             `json:"user_id"`
+	Month      string             `json:"month"` // formatted as 2006-01
+	CostType   CostType           `json:"cost_type"`
+	Strategy   EstimationStrategy `json:"strategy"` // configured strategy of the cost type at the time of the estimation
+	Timestamp  time.Time          `json:"timestamp"`
+	Progress   float64            `json:"progress"` // fraction of the month, which has passed at the time of the estimation
+	Estimation float64            `json:"estimation"`
+	Actual     *float64           `json:"actual,omitempty"` // set after the month is closed
+}
+
+// EstimationAccuracy summarizes the errors of the evaluated estimations of a cost type and strategy
+type EstimationAccuracy struct {
+	CostType    CostType           `json:"cost_type"`
+	Strategy    EstimationStrategy `json:"strategy"`
+	Records     int                `json:"records"`     // number of evaluated records with actual cost
+	Unevaluated int                `json:"unevaluated"` // number of records without actual cost yet
+	ZeroActual  int                `json:"zero_actual"` // number of evaluated records with an actual cost of zero
+	Mape        float64            `json:"mape"`        // mean absolute percentage error
+	Bias        float64            `json:"bias"`        // mean percentage error, positive if the estimations are too high
+}
+
+// GetEstimationAccuracies computes the accuracy per cost type and strategy. Records, which are not evaluated yet or
+// have an actual cost of zero, are not part of the errors, because their percentage error is undefined. They are
+// counted separately.
+func GetEstimationAccuracies(records []EstimationRecord) []EstimationAccuracy {
+	index := map[string]int{}
+	result := []EstimationAccuracy{}
+	for _, record := range records {
+		key := record.CostType + "/" + record.Strategy
+		i, ok := index[key]
+		if !ok {
+			i = len(result)
+			index[key] = i
+			result = append(result, EstimationAccuracy{CostType: record.CostType, Strategy: record.Strategy})
+		}
+		if record.Actual == nil {
+			result[i].Unevaluated++
+			continue
+		}
+		if *record.Actual == 0 {
+			result[i].ZeroActual++
+			continue
+		}
+		percentageError := (record.Estimation - *record.Actual) / math.Abs(*record.Actual) * 100
+		result[i].Records++
+		result[i].Mape += math.Abs(percentageError)
+		result[i].Bias += percentageError
+	}
+	for i := range result {
+		if result[i].Records == 0 {
+			continue
+		}
+		result[i].Mape /= float64(result[i].Records)
+		result[i].Bias /= float64(result[i].Records)
+	}
+	slices.SortFunc(result, func(a, b EstimationAccuracy) int {
+		if c := strings.Compare(a.CostType, b.CostType); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Strategy, b.Strategy)
+	})
+	return result
+}
@@ -0,0 +1,52 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"testing"
+)
+
+func TestGetEstimationAccuracies(t *testing.T) {
+	actual := func(v float64) *float64 {
+		return &v
+	}
+	records := []EstimationRecord{
+		{CostType: CostTypeImports, Strategy: EstimationStrategyLast24h, Estimation: 120, Actual: actual(100)},
+		{CostType: CostTypeAnalytics, Strategy: EstimationStrategyLast24h, Estimation: 110, Actual: actual(100)},
+		{CostType: CostTypeAnalytics, Strategy: EstimationStrategyLast24h, Estimation: 70, Actual: actual(100)},
+		{CostType: CostTypeAnalytics, Strategy: EstimationStrategyLast24h, Estimation: 50},
+		{CostType: CostTypeAnalytics, Strategy: EstimationStrategyLast24h, Estimation: 50, Actual: actual(0)},
+	}
+	accuracies := GetEstimationAccuracies(records)
+	if len(accuracies) != 2 {
+		t.Fatalf("unexpected accuracies %#v", accuracies)
+	}
+	analytics := accuracies[0]
+	if analytics.CostType != CostTypeAnalytics || analytics.Records != 2 || analytics.Mape != 20 || analytics.Bias != -10 ||
+		analytics.Unevaluated != 1 || analytics.ZeroActual != 1 {
+		t.Errorf("unexpected accuracy %#v", analytics)
+	}
+	imports := accuracies[1]
+	if imports.CostType != CostTypeImports || imports.Records != 1 || imports.Mape != 20 || imports.Bias != 20 {
+		t.Errorf("unexpected accuracy %#v", imports)
+	}
+
+	accuracies = GetEstimationAccuracies([]EstimationRecord{{CostType: CostTypeImports, Strategy: EstimationStrategyLast24h, Estimation: 1}})
+	if len(accuracies) != 1 || accuracies[0].Unevaluated != 1 || accuracies[0].Mape != 0 {
+		t.Errorf("unexpected accuracies of unevaluated records %#v", accuracies)
+	}
+}
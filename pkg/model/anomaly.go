@@ -0,0 +1,99 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"math"
+)
+
+const AnomalyDayFormat = "2006-01-02"
+
+type CostAnomaly struct {
+	UserId    string   `json:"user_id"`
+	Day       string   `json:"day"` // formatted as AnomalyDayFormat
+	Path      []string `json:"path"`
+	Cost      float64  `json:"cost"`
+	Mean      float64  `json:"mean"` // mean daily cost of the history
+	StdDev    float64  `json:"std_dev"`
+	ZScore    float64  `json:"z_score"`       // number of standard deviations the cost exceeds the mean
+	Magnitude float64  `json:"magnitude"`     // cost divided by mean, 0 for new nodes
+	New       bool     `json:"new,omitempty"` // the node had no cost in the history
+	Currency  string   `json:"currency,omitempty"`
+}
+
+// DetectAnomalies compares the cost of each node of day with the costs of the same node in the daily trees of history.
+// Nodes missing in a tree of the history count as zero cost. A node is reported, if its cost exceeds the mean of
+// the history by more than threshold standard deviations. New nodes, which had no cost in the whole history, are
+// reported, if they have any cost. Other nodes with constant history are never reported.
+func DetectAnomalies(day CostTree, history []CostTree, threshold float64) []CostAnomaly {
+	result := []CostAnomaly{}
+	if len(history) < 2 {
+		return result
+	}
+	historyCosts := map[string][]float64{}
+	for i, tree := range history {
+		for _, row := range tree.Flatten() {
+			key := pathKey(row.Path)
+			if _, ok := historyCosts[key]; !ok {
+				historyCosts[key] = make([]float64, len(history))
+			}
+			historyCosts[key][i] = row.Month.Total().InexactFloat64()
+		}
+	}
+	for _, row := range day.Flatten() {
+		costs, ok := historyCosts[pathKey(row.Path)]
+		if !ok {
+			costs = make([]float64, len(history))
+		}
+		mean, stdDev := meanStdDev(costs)
+		cost := row.Month.Total().InexactFloat64()
+		if mean == 0 && stdDev == 0 {
+			if cost > 0 {
+				result = append(result, CostAnomaly{Path: row.Path, Cost: cost, New: true, Currency: row.Currency})
+			}
+			continue
+		}
+		if stdDev == 0 {
+			continue
+		}
+		zScore := (cost - mean) / stdDev
+		if zScore < threshold {
+			continue
+		}
+		result = append(result, CostAnomaly{
+			Path:      row.Path,
+			Cost:      cost,
+			Mean:      mean,
+			StdDev:    stdDev,
+			ZScore:    zScore,
+			Magnitude: cost / mean,
+			Currency:  row.Currency,
+		})
+	}
+	return result
+}
+
+func meanStdDev(values []float64) (mean float64, stdDev float64) {
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		stdDev += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(stdDev / float64(len(values)))
+}
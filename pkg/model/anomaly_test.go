@@ -0,0 +1,81 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetectAnomalies(t *testing.T) {
+	daily := func(pipeline float64, other float64) CostTree {
+		return CostTree{
+			CostTypeAnalytics: {
+				CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(pipeline + other)}},
+				Children: map[string]CostWithChildren{
+					"pipeline": {CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(pipeline)}}},
+					"other":    {CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(other)}}},
+				},
+			},
+		}
+	}
+	history := []CostTree{daily(1, 5), daily(1.2, 5.5), daily(0.8, 4.5), daily(1, 5)}
+
+	anomalies := DetectAnomalies(daily(1.1, 5.2), history, 3)
+	if len(anomalies) != 0 {
+		t.Errorf("unexpected anomalies %#v", anomalies)
+	}
+
+	anomalies = DetectAnomalies(daily(3, 5), history, 3)
+	if len(anomalies) != 2 || !slices.Equal(anomalies[0].Path, []string{CostTypeAnalytics}) || !slices.Equal(anomalies[1].Path, []string{CostTypeAnalytics, "pipeline"}) {
+		t.Fatalf("unexpected anomalies %#v", anomalies)
+	}
+	if anomalies[1].Mean != 1 || anomalies[1].Magnitude != 3 || anomalies[1].ZScore < 14 {
+		t.Errorf("unexpected anomaly %#v", anomalies[1])
+	}
+
+	anomalies = DetectAnomalies(CostTree{CostTypeImports: {CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(100)}}}}, history, 3)
+	if len(anomalies) != 1 || !anomalies[0].New || anomalies[0].Cost != 100 || !slices.Equal(anomalies[0].Path, []string{CostTypeImports}) {
+		t.Errorf("new nodes without history should be reported %#v", anomalies)
+	}
+}
+
+func TestDetectAnomaliesPathsWithSeparator(t *testing.T) {
+	// process names may contain "/", which must not be confused with nested nodes
+	nested := func(cost float64) CostTree {
+		return CostTree{
+			CostTypeProcesses: {
+				CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(cost)}},
+				Children: map[string]CostWithChildren{
+					"a": {
+						CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(cost)}},
+						Children: map[string]CostWithChildren{
+							"b": {CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(cost)}}},
+						},
+					},
+				},
+			},
+		}
+	}
+	history := []CostTree{nested(1), nested(1.2), nested(0.8), nested(1)}
+	day := nested(1)
+	day[CostTypeProcesses].Children["a/b"] = CostWithChildren{CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(1)}}}
+	anomalies := DetectAnomalies(day, history, 3)
+	if len(anomalies) != 1 || !slices.Equal(anomalies[0].Path, []string{CostTypeProcesses, "a/b"}) || !anomalies[0].New {
+		t.Errorf("unexpected anomalies %#v", anomalies)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const BillingPeriodFormat = "2006-01" // billing periods are named after the month they start in
+
+// BillingCalendar splits time into billing periods, which start at midnight of the anchor day of every month in the
+// time zone of the calendar. The zero value uses calendar months in UTC.
+type BillingCalendar struct {
+	Location  *time.Location // nil is UTC
+	AnchorDay int            // 1 to 28, 0 is 1
+}
+
+func NewBillingCalendar(timeZone string, anchorDay int) (calendar BillingCalendar, err error) {
+	if anchorDay < 0 || anchorDay > 28 {
+		return calendar, fmt.Errorf("anchor day %v is not between 1 and 28", anchorDay)
+	}
+	calendar.AnchorDay = anchorDay
+	if timeZone != "" {
+		calendar.Location, err = time.LoadLocation(timeZone)
+		if err != nil {
+			return calendar, err
+		}
+	}
+	return calendar, nil
+}
+
+func (b BillingCalendar) location() *time.Location {
+	if b.Location == nil {
+		return time.UTC
+	}
+	return b.Location
+}
+
+func (b BillingCalendar) anchorDay() int {
+	if b.AnchorDay == 0 {
+		return 1
+	}
+	return b.AnchorDay
+}
+
+// PeriodStart returns the start of the billing period, which contains t
+func (b BillingCalendar) PeriodStart(t time.Time) time.Time {
+	t = t.In(b.location())
+	start := time.Date(t.Year(), t.Month(), b.anchorDay(), 0, 0, 0, 0, b.location())
+	if t.Before(start) {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start
+}
+
+// DayStart returns midnight of the day, which contains t, in the time zone of the calendar
+func (b BillingCalendar) DayStart(t time.Time) time.Time {
+	t = t.In(b.location())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, b.location())
+}
+
+// PeriodEnd returns the end of the billing period, which contains t. The end is the start of the next period.
+func (b BillingCalendar) PeriodEnd(t time.Time) time.Time {
+	return b.PeriodStart(t).AddDate(0, 1, 0)
+}
+
+// PeriodName returns the name of the billing period, which contains t, formatted as BillingPeriodFormat
+func (b BillingCalendar) PeriodName(t time.Time) string {
+	return b.PeriodStart(t).Format(BillingPeriodFormat)
+}
+
+// Period returns the start and end of the billing period with the name, formatted as BillingPeriodFormat
+func (b BillingCalendar) Period(name string) (start time.Time, end time.Time, err error) {
+	month, err := time.ParseInLocation(BillingPeriodFormat, name, b.location())
+	if err != nil {
+		return start, end, err
+	}
+	start = time.Date(month.Year(), month.Month(), b.anchorDay(), 0, 0, 0, 0, b.location())
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+const PeriodCurrentMonth = "current_month"
+const PeriodLastMonth = "last_month"
+const PeriodLast7d = "last_7d"
+const PeriodQuarterToDate = "quarter_to_date"
+const PeriodYearToDate = "year_to_date"
+
+// Preset returns the time range of the named period at now. Months, quarters and years consist of billing periods.
+func (b BillingCalendar) Preset(period string, now time.Time) (start time.Time, end time.Time, err error) {
+	current := b.PeriodStart(now)
+	switch period {
+	case PeriodCurrentMonth:
+		return current, now, nil
+	case PeriodLastMonth:
+		return current.AddDate(0, -1, 0), current, nil
+	case PeriodLast7d:
+		return now.AddDate(0, 0, -7), now, nil
+	case PeriodQuarterToDate:
+		quarter := time.Month((int(current.Month())-1)/3*3 + 1)
+		return time.Date(current.Year(), quarter, b.anchorDay(), 0, 0, 0, 0, b.location()), now, nil
+	case PeriodYearToDate:
+		return time.Date(current.Year(), time.January, b.anchorDay(), 0, 0, 0, 0, b.location()), now, nil
+	default:
+		return start, end, fmt.Errorf("unknown period %v", period)
+	}
+}
+
+// ParseTime parses RFC3339 timestamps and dates formatted as time.DateOnly. Dates are midnight in the time zone
+// of the calendar.
+func (b BillingCalendar) ParseTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, value, b.location())
+}
@@ -0,0 +1,96 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBillingCalendar(t *testing.T) {
+	utc := BillingCalendar{}
+	if start := utc.PeriodStart(time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC)); !start.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error(start)
+	}
+	if end := utc.PeriodEnd(time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC)); !end.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error(end)
+	}
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	calendar, err := NewBillingCalendar("Europe/Berlin", 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := time.Date(2024, 3, 14, 23, 30, 0, 0, time.UTC) // 2024-03-15 00:30 in Berlin
+	if start := calendar.PeriodStart(ts); !start.Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, berlin)) {
+		t.Error(start)
+	}
+	if end := calendar.PeriodEnd(ts); !end.Equal(time.Date(2024, 4, 15, 0, 0, 0, 0, berlin)) {
+		t.Error(end)
+	}
+	if day := calendar.DayStart(ts); !day.Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, berlin)) {
+		t.Error(day)
+	}
+	if name := calendar.PeriodName(ts.Add(-time.Hour)); name != "2024-02" {
+		t.Error(name)
+	}
+	start, end, err := calendar.Period("2024-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !start.Equal(time.Date(2024, 2, 15, 0, 0, 0, 0, berlin)) || !end.Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, berlin)) {
+		t.Error(start, end)
+	}
+
+	_, err = NewBillingCalendar("UTC", 31)
+	if err == nil {
+		t.Error("expected error for anchor day 31")
+	}
+}
+
+func TestBillingCalendarPreset(t *testing.T) {
+	calendar := BillingCalendar{AnchorDay: 15}
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	tests := map[string][2]time.Time{
+		PeriodCurrentMonth:  {time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC), now},
+		PeriodLastMonth:     {time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC)},
+		PeriodLast7d:        {time.Date(2024, 5, 3, 12, 0, 0, 0, time.UTC), now},
+		PeriodQuarterToDate: {time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC), now},
+		PeriodYearToDate:    {time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), now},
+	}
+	for period, expected := range tests {
+		start, end, err := calendar.Preset(period, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !start.Equal(expected[0]) || !end.Equal(expected[1]) {
+			t.Error(period, start, end)
+		}
+	}
+	_, _, err := calendar.Preset("unknown", now)
+	if err == nil {
+		t.Error("expected error for unknown period")
+	}
+
+	date, err := calendar.ParseTime("2024-05-01")
+	if err != nil || !date.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error(date, err)
+	}
+}
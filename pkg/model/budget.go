@@ -0,0 +1,80 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Budget struct {
+	Id       string   `json:"id"`
+	UserId   string   `json:"user_id"`
+	CostType CostType `json:"cost_type,omitempty"` // empty for a budget over all cost types
+	Amount   float64  `json:"amount"`              // per month, in the currency of the cost tree
+}
+
+type BudgetAlert struct {
+	BudgetId   string    `json:"budget_id"`
+	UserId     string    `json:"user_id"`
+	CostType   CostType  `json:"cost_type,omitempty"`
+	Month      string    `json:"month"`
+	Threshold  float64   `json:"threshold"` // percent of the budget
+	Amount     float64   `json:"amount"`
+	Estimation float64   `json:"estimation"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+func (b Budget) Validate() error {
+	if b.Amount <= 0 {
+		return errors.New("budget amount must be positive")
+	}
+	if b.CostType != "" && !slices.Contains(CostTypes, b.CostType) {
+		return fmt.Errorf("unknown cost type %v", b.CostType)
+	}
+	return nil
+}
+
+// Estimation returns the estimated cost of the current month, which is covered by the budget
+func (b Budget) Estimation(tree CostTree) float64 {
+	if b.CostType != "" {
+		return tree[b.CostType].EstimationMonth.Total().InexactFloat64()
+	}
+	result := decimal.Zero
+	for _, child := range tree {
+		result = result.Add(child.EstimationMonth.Total())
+	}
+	return result.InexactFloat64()
+}
+
+// ReachedThresholds returns all thresholds (in percent of the budget amount), which are reached by the estimation
+func (b Budget) ReachedThresholds(estimation float64, thresholds []float64) []float64 {
+	result := []float64{}
+	if b.Amount <= 0 {
+		return result
+	}
+	for _, threshold := range thresholds {
+		if estimation*100 >= threshold*b.Amount {
+			result = append(result, threshold)
+		}
+	}
+	return result
+}
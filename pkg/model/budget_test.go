@@ -0,0 +1,61 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBudgetEstimation(t *testing.T) {
+	tree := CostTree{
+		CostTypeAnalytics: {CostWithEstimation: CostWithEstimation{EstimationMonth: CostEntry{Cpu: ToDecimal(10), Ram: ToDecimal(5)}}},
+		CostTypeImports:   {CostWithEstimation: CostWithEstimation{EstimationMonth: CostEntry{Storage: ToDecimal(2.5), RequestsCost: ToDecimal(2.5)}}},
+	}
+	if e := (Budget{Amount: 1}).Estimation(tree); e != 20 {
+		t.Errorf("unexpected overall estimation %v", e)
+	}
+	if e := (Budget{CostType: CostTypeImports, Amount: 1}).Estimation(tree); e != 5 {
+		t.Errorf("unexpected imports estimation %v", e)
+	}
+	if e := (Budget{CostType: CostTypeDevices, Amount: 1}).Estimation(tree); e != 0 {
+		t.Errorf("unexpected devices estimation %v", e)
+	}
+}
+
+func TestBudgetReachedThresholds(t *testing.T) {
+	budget := Budget{Amount: 40}
+	thresholds := []float64{50, 80, 100}
+	if reached := budget.ReachedThresholds(19.99, thresholds); len(reached) != 0 {
+		t.Errorf("unexpected thresholds %v", reached)
+	}
+	if reached := budget.ReachedThresholds(32, thresholds); !slices.Equal(reached, []float64{50, 80}) {
+		t.Errorf("unexpected thresholds %v", reached)
+	}
+	if reached := budget.ReachedThresholds(50, thresholds); !slices.Equal(reached, thresholds) {
+		t.Errorf("unexpected thresholds %v", reached)
+	}
+	if err := (Budget{}).Validate(); err == nil {
+		t.Error("expected error for empty budget")
+	}
+	if err := (Budget{Amount: 1, CostType: "analytic"}).Validate(); err == nil {
+		t.Error("expected error for unknown cost type")
+	}
+	if err := (Budget{Amount: 1, CostType: CostTypeApiCalls}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
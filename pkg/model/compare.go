@@ -0,0 +1,95 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/shopspring/decimal"
+)
+
+type CostDeltaStatus = string
+
+const CostDeltaAdded CostDeltaStatus = "added"
+const CostDeltaRemoved CostDeltaStatus = "removed"
+const CostDeltaChanged CostDeltaStatus = "changed"
+const CostDeltaUnchanged CostDeltaStatus = "unchanged"
+
+// CostDelta is the change of the total cost of a tree node from period a to period b
+type CostDelta struct {
+	Path     []string        `json:"path"`
+	Status   CostDeltaStatus `json:"status"`
+	A        float64         `json:"a"`
+	B        float64         `json:"b"`
+	Absolute float64         `json:"absolute"`           // b - a
+	Relative *float64        `json:"relative,omitempty"` // (b - a) / a, missing if a is zero
+	Currency string          `json:"currency,omitempty"`
+
+	a, b decimal.Decimal
+}
+
+// CompareCostTrees returns the deltas of all nodes, which exist in at least one of the trees,
+// sorted by the largest absolute change
+func CompareCostTrees(a CostTree, b CostTree) []CostDelta {
+	deltas := map[string]*CostDelta{}
+	keys := []string{}
+	for i, tree := range []CostTree{a, b} {
+		for _, row := range tree.Flatten() {
+			key := pathKey(row.Path)
+			delta, ok := deltas[key]
+			if !ok {
+				delta = &CostDelta{Path: row.Path, Status: CostDeltaAdded}
+				deltas[key] = delta
+				keys = append(keys, key)
+				if i == 0 {
+					delta.Status = CostDeltaRemoved
+				}
+			} else {
+				delta.Status = CostDeltaChanged
+			}
+			if row.Currency != "" {
+				delta.Currency = row.Currency
+			}
+			if i == 0 {
+				delta.a = row.Month.Total()
+			} else {
+				delta.b = row.Month.Total()
+			}
+		}
+	}
+	result := []CostDelta{}
+	for _, key := range keys {
+		delta := deltas[key]
+		delta.A = delta.a.InexactFloat64()
+		delta.B = delta.b.InexactFloat64()
+		delta.Absolute = delta.b.Sub(delta.a).InexactFloat64()
+		if delta.Status == CostDeltaChanged && delta.Absolute == 0 {
+			delta.Status = CostDeltaUnchanged
+		}
+		if delta.A != 0 {
+			relative := delta.Absolute / delta.A
+			delta.Relative = &relative
+		}
+		result = append(result, *delta)
+	}
+	slices.SortStableFunc(result, func(x, y CostDelta) int {
+		return cmp.Compare(math.Abs(y.Absolute), math.Abs(x.Absolute))
+	})
+	return result
+}
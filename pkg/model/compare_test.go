@@ -0,0 +1,80 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompareCostTrees(t *testing.T) {
+	node := func(cost float64, children map[string]CostWithChildren) CostWithChildren {
+		return CostWithChildren{CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(cost)}}, Children: children}
+	}
+	a := CostTree{
+		CostTypeAnalytics: node(10, map[string]CostWithChildren{
+			"p1": node(6, nil),
+			"p2": node(4, nil),
+		}),
+		CostTypeImports: node(1, nil),
+	}
+	b := CostTree{
+		CostTypeAnalytics: node(13, map[string]CostWithChildren{
+			"p1": node(6, nil),
+			"p3": node(7, nil),
+		}),
+		CostTypeImports: node(0.5, nil),
+	}
+	deltas := CompareCostTrees(a, b)
+	paths := [][]string{}
+	for _, delta := range deltas {
+		paths = append(paths, delta.Path)
+	}
+	expected := [][]string{{CostTypeAnalytics, "p3"}, {CostTypeAnalytics, "p2"}, {CostTypeAnalytics}, {CostTypeImports}, {CostTypeAnalytics, "p1"}}
+	if !slices.EqualFunc(paths, expected, slices.Equal[[]string]) {
+		t.Fatalf("unexpected order %v", paths)
+	}
+	if deltas[0].Status != CostDeltaAdded || deltas[0].Relative != nil || deltas[0].Absolute != 7 {
+		t.Errorf("unexpected delta %#v", deltas[0])
+	}
+	if deltas[1].Status != CostDeltaRemoved || *deltas[1].Relative != -1 {
+		t.Errorf("unexpected delta %#v", deltas[1])
+	}
+	if deltas[2].Status != CostDeltaChanged || *deltas[2].Relative != 0.3 {
+		t.Errorf("unexpected delta %#v", deltas[2])
+	}
+	if deltas[3].Absolute != -0.5 || deltas[4].Status != CostDeltaUnchanged {
+		t.Errorf("unexpected deltas %#v", deltas[3:])
+	}
+}
+
+func TestCompareCostTreesPathsWithSeparator(t *testing.T) {
+	node := func(cost float64, children map[string]CostWithChildren) CostWithChildren {
+		return CostWithChildren{CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(cost)}}, Children: children}
+	}
+	// process names may contain "/", which must not be confused with nested nodes
+	a := CostTree{CostTypeProcesses: node(1, map[string]CostWithChildren{"a": node(1, map[string]CostWithChildren{"b": node(1, nil)})})}
+	b := CostTree{CostTypeProcesses: node(1, map[string]CostWithChildren{"a/b": node(1, nil)})}
+	statuses := map[string]CostDeltaStatus{}
+	for _, delta := range CompareCostTrees(a, b) {
+		statuses[strings.Join(delta.Path, "|")] = delta.Status
+	}
+	if len(statuses) != 4 || statuses["process|a|b"] != CostDeltaRemoved || statuses["process|a/b"] != CostDeltaAdded || statuses["process"] != CostDeltaUnchanged {
+		t.Errorf("unexpected deltas %v", statuses)
+	}
+}
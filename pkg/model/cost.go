@@ -16,23 +16,146 @@
 
 package model
 
+import (
+	"encoding/json"
+
+	"github.com/shopspring/decimal"
+)
+
 type CostWithEstimation struct {
-	Month           CostEntry `json:"month"`
-	EstimationMonth CostEntry `json:"estimation_month"`
+	Month                CostEntry `json:"month"`
+	EstimationMonth      CostEntry `json:"estimation_month"`
+	EstimationMonthLower CostEntry `json:"estimation_month_lower"` // 10th percentile of the estimation
+	EstimationMonthUpper CostEntry `json:"estimation_month_upper"` // 90th percentile of the estimation
+	Currency             string    `json:"currency,omitempty"`
 }
 
+// entries returns pointers to all cost entries
+func (a *CostWithEstimation) entries() []*CostEntry {
+	return []*CostEntry{&a.Month, &a.EstimationMonth, &a.EstimationMonthLower, &a.EstimationMonthUpper}
+}
+
+// CostEntry holds costs and the quantities they are based on. Costs are exact decimals, which are encoded as JSON
+// numbers.
 type CostEntry struct {
-	Cpu      float64 `json:"cpu,omitempty"`
-	Ram      float64 `json:"ram,omitempty"`
-	Storage  float64 `json:"storage,omitempty"`
-	Requests float64 `json:"requests,omitempty"`
+	Cpu      decimal.Decimal
+	Ram      decimal.Decimal
+	Storage  decimal.Decimal
+	Network  decimal.Decimal
+	Requests float64
+
+	RequestsCost decimal.Decimal
+
+	CpuHours       float64 // core hours
+	RamGbHours     float64 // GB hours
+	StorageGbHours float64 // GB hours
+	NetworkGb      float64 // GB transmitted and received
+}
+
+type costEntryJson struct {
+	Cpu      json.Number `json:"cpu,omitempty"`
+	Ram      json.Number `json:"ram,omitempty"`
+	Storage  json.Number `json:"storage,omitempty"`
+	Network  json.Number `json:"network,omitempty"`
+	Requests float64     `json:"requests,omitempty"`
+
+	RequestsCost json.Number `json:"requests_cost,omitempty"`
+
+	CpuHours       float64 `json:"cpu_hours,omitempty"`
+	RamGbHours     float64 `json:"ram_gb_hours,omitempty"`
+	StorageGbHours float64 `json:"storage_gb_hours,omitempty"`
+	NetworkGb      float64 `json:"network_gb,omitempty"`
+}
+
+func (a CostEntry) MarshalJSON() ([]byte, error) {
+	number := func(d decimal.Decimal) json.Number {
+		if d.IsZero() {
+			return ""
+		}
+		return json.Number(d.String())
+	}
+	return json.Marshal(costEntryJson{
+		Cpu:            number(a.Cpu),
+		Ram:            number(a.Ram),
+		Storage:        number(a.Storage),
+		Network:        number(a.Network),
+		Requests:       a.Requests,
+		RequestsCost:   number(a.RequestsCost),
+		CpuHours:       a.CpuHours,
+		RamGbHours:     a.RamGbHours,
+		StorageGbHours: a.StorageGbHours,
+		NetworkGb:      a.NetworkGb,
+	})
+}
+
+func (a *CostEntry) UnmarshalJSON(b []byte) (err error) {
+	temp := costEntryJson{}
+	err = json.Unmarshal(b, &temp)
+	if err != nil {
+		return err
+	}
+	parse := func(n json.Number) (decimal.Decimal, error) {
+		if n == "" {
+			return decimal.Zero, nil
+		}
+		return decimal.NewFromString(n.String())
+	}
+	*a = CostEntry{
+		Requests:       temp.Requests,
+		CpuHours:       temp.CpuHours,
+		RamGbHours:     temp.RamGbHours,
+		StorageGbHours: temp.StorageGbHours,
+		NetworkGb:      temp.NetworkGb,
+	}
+	if a.Cpu, err = parse(temp.Cpu); err != nil {
+		return err
+	}
+	if a.Ram, err = parse(temp.Ram); err != nil {
+		return err
+	}
+	if a.Storage, err = parse(temp.Storage); err != nil {
+		return err
+	}
+	if a.Network, err = parse(temp.Network); err != nil {
+		return err
+	}
+	a.RequestsCost, err = parse(temp.RequestsCost)
+	return err
 }
 
 func (a *CostEntry) Add(b CostEntry) {
-	a.Cpu += b.Cpu
-	a.Ram += b.Ram
-	a.Storage += b.Storage
-	a.Requests += b.Requests
+	a.Cpu = a.Cpu.Add(b.Cpu)
+	a.Ram = a.Ram.Add(b.Ram)
+	a.Storage = a.Storage.Add(b.Storage)
+	a.Network = a.Network.Add(b.Network)
+	a.Requests = addDecimal(a.Requests, b.Requests)
+	a.RequestsCost = a.RequestsCost.Add(b.RequestsCost)
+	a.CpuHours = addDecimal(a.CpuHours, b.CpuHours)
+	a.RamGbHours = addDecimal(a.RamGbHours, b.RamGbHours)
+	a.StorageGbHours = addDecimal(a.StorageGbHours, b.StorageGbHours)
+	a.NetworkGb = addDecimal(a.NetworkGb, b.NetworkGb)
+}
+
+// Total returns the sum of all costs of the entry
+func (a CostEntry) Total() decimal.Decimal {
+	return a.Cpu.Add(a.Ram).Add(a.Storage).Add(a.Network).Add(a.RequestsCost)
+}
+
+// Scale returns a copy of the entry with all costs and quantities multiplied by factor
+func (a CostEntry) Scale(factor float64) CostEntry {
+	f := ToDecimal(factor)
+	return CostEntry{
+		Cpu:            a.Cpu.Mul(f),
+		Ram:            a.Ram.Mul(f),
+		Storage:        a.Storage.Mul(f),
+		Network:        a.Network.Mul(f),
+		Requests:       a.Requests * factor,
+		RequestsCost:   a.RequestsCost.Mul(f),
+		CpuHours:       a.CpuHours * factor,
+		RamGbHours:     a.RamGbHours * factor,
+		StorageGbHours: a.StorageGbHours * factor,
+		NetworkGb:      a.NetworkGb * factor,
+	}
 }
 
 type CostOverview = map[CostType]CostWithEstimation
@@ -47,19 +170,138 @@ const CostTypeApiCalls CostType = "API Calls"
 const CostTypeExports CostType = "Exports"
 const CostTypeDevices CostType = "Devices"
 const CostTypeProcesses CostType = "process"
+const CostTypeTiers CostType = "Tiers"
+const CostTypeOverhead CostType = "overhead"
+
+// CostTypes are the top level keys of a CostTree
+var CostTypes = []CostType{CostTypeAnalytics, CostTypeImports, CostTypeApiCalls, CostTypeExports, CostTypeDevices, CostTypeProcesses, CostTypeTiers, CostTypeOverhead}
 
 type CostControllers = map[string]CostWithEstimation
 
 type CostControllerEntries = map[string]CostEntry
 
+// BillingMode selects which cpu and ram quantities of containers are billed
+type BillingMode = string
+
+const BillingModeUsage BillingMode = "usage"       // actual usage
+const BillingModeRequests BillingMode = "requests" // resource requests
+const BillingModeMax BillingMode = "max"           // max of resource requests and actual usage
+
+// BillingBasisMixed is reported as billing basis of nodes, for which both usage and requests have been billed
+const BillingBasisMixed BillingMode = "mixed"
+
+// CombineBillingBasis returns the billing basis of a node with parts billed on the given bases.
+// Empty bases (e.g. for storage) are ignored.
+func CombineBillingBasis(bases ...BillingMode) (result BillingMode) {
+	for _, basis := range bases {
+		switch {
+		case basis == "" || basis == result:
+		case result == "":
+			result = basis
+		default:
+			result = BillingBasisMixed
+		}
+	}
+	return result
+}
+
 type CostWithChildren struct {
 	CostWithEstimation
-	Children map[string]CostWithChildren `json:"children,omitempty"`
+	BillingBasis BillingMode                  `json:"billing_basis,omitempty"`
+	Children     map[string]CostWithChildren  `json:"children,omitempty"`
+	Tiers        map[TierDimension]TierUsages `json:"tiers,omitempty"`
 }
 
 type CostTree map[string]CostWithChildren
 
+// Add adds all entries of b. The bounds of the estimation are summed up, which overestimates the range of the sum,
+// but keeps the bounds of a node consistent with the bounds of its children.
 func (a *CostWithEstimation) Add(b CostWithEstimation) {
 	a.Month.Add(b.Month)
 	a.EstimationMonth.Add(b.EstimationMonth)
+	a.EstimationMonthLower.Add(b.EstimationMonthLower)
+	a.EstimationMonthUpper.Add(b.EstimationMonthUpper)
+}
+
+// Scale returns a copy with all costs and quantities multiplied by factor
+func (a CostWithEstimation) Scale(factor float64) CostWithEstimation {
+	return CostWithEstimation{
+		Month:                a.Month.Scale(factor),
+		EstimationMonth:      a.EstimationMonth.Scale(factor),
+		EstimationMonthLower: a.EstimationMonthLower.Scale(factor),
+		EstimationMonthUpper: a.EstimationMonthUpper.Scale(factor),
+		Currency:             a.Currency,
+	}
+}
+
+// AsEstimation returns a copy of the tree with the estimations and their bounds set to the measured cost,
+// e.g. for parts of a time range, which are not projected
+func (a CostTree) AsEstimation() CostTree {
+	result := make(CostTree, len(a))
+	for k, v := range a {
+		result[k] = v.AsEstimation()
+	}
+	return result
+}
+
+// AsEstimation returns a copy of the node and its children with the estimations and their bounds set to the measured cost
+func (a CostWithChildren) AsEstimation() CostWithChildren {
+	a.EstimationMonth = a.Month
+	a.EstimationMonthLower = a.Month
+	a.EstimationMonthUpper = a.Month
+	if a.Tiers != nil {
+		tiers := make(map[TierDimension]TierUsages, len(a.Tiers))
+		for k, v := range a.Tiers {
+			tiers[k] = TierUsages{Month: v.Month, EstimationMonth: v.Month}
+		}
+		a.Tiers = tiers
+	}
+	if a.Children != nil {
+		a.Children = map[string]CostWithChildren(CostTree(a.Children).AsEstimation())
+	}
+	return a
+}
+
+// Merge returns the sum of both trees, e.g. to combine the trees of consecutive time ranges
+func (a CostTree) Merge(b CostTree) CostTree {
+	result := CostTree{}
+	for k, v := range a {
+		result[k] = v
+	}
+	for k, v := range b {
+		existing, ok := result[k]
+		if !ok {
+			result[k] = v
+			continue
+		}
+		result[k] = existing.Merge(v)
+	}
+	return result
+}
+
+// Merge returns the sum of both nodes and their children
+func (a CostWithChildren) Merge(b CostWithChildren) CostWithChildren {
+	result := a
+	result.Add(b.CostWithEstimation)
+	if result.Currency == "" {
+		result.Currency = b.Currency
+	}
+	result.BillingBasis = CombineBillingBasis(a.BillingBasis, b.BillingBasis)
+	if a.Children != nil || b.Children != nil {
+		result.Children = map[string]CostWithChildren(CostTree(a.Children).Merge(b.Children))
+	}
+	if a.Tiers != nil || b.Tiers != nil {
+		result.Tiers = map[TierDimension]TierUsages{}
+		for k, v := range a.Tiers {
+			result.Tiers[k] = v
+		}
+		for k, v := range b.Tiers {
+			existing := result.Tiers[k]
+			result.Tiers[k] = TierUsages{
+				Month:           append(append([]TierUsage{}, existing.Month...), v.Month...),
+				EstimationMonth: append(append([]TierUsage{}, existing.EstimationMonth...), v.EstimationMonth...),
+			}
+		}
+	}
+	return result
 }
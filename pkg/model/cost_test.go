@@ -0,0 +1,79 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import "testing"
+
+func TestCostTreeMerge(t *testing.T) {
+	a := CostTree{
+		CostTypeAnalytics: {
+			CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(1), CpuHours: 10}, Currency: "EUR"},
+			Children: map[string]CostWithChildren{
+				"pipeline1": {CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(1), CpuHours: 10}}},
+			},
+		},
+	}
+	b := CostTree{
+		CostTypeAnalytics: {
+			CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(2), CpuHours: 20}, Currency: "EUR"},
+			Children: map[string]CostWithChildren{
+				"pipeline1": {CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(0.5)}}},
+				"pipeline2": {CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(1.5)}}},
+			},
+		},
+		CostTypeApiCalls: {
+			CostWithEstimation: CostWithEstimation{Month: CostEntry{Requests: 1000, RequestsCost: ToDecimal(0.1)}},
+		},
+	}
+
+	merged := a.Merge(b)
+	analytics := merged[CostTypeAnalytics]
+	if !analytics.Month.Cpu.Equal(ToDecimal(3)) || analytics.Month.CpuHours != 30 || analytics.Currency != "EUR" {
+		t.Errorf("unexpected analytics node %#v", analytics)
+	}
+	if !analytics.Children["pipeline1"].Month.Cpu.Equal(ToDecimal(1.5)) || !analytics.Children["pipeline2"].Month.Cpu.Equal(ToDecimal(1.5)) {
+		t.Errorf("unexpected children %#v", analytics.Children)
+	}
+	if merged[CostTypeApiCalls].Month.Requests != 1000 {
+		t.Errorf("unexpected api calls node %#v", merged[CostTypeApiCalls])
+	}
+	if !a[CostTypeAnalytics].Month.Cpu.Equal(ToDecimal(1)) || len(a[CostTypeAnalytics].Children) != 1 {
+		t.Error("merge must not modify its input")
+	}
+}
+
+func TestEstimationBounds(t *testing.T) {
+	child := CostWithChildren{CostWithEstimation: CostWithEstimation{
+		EstimationMonth:      CostEntry{Cpu: ToDecimal(0.333333)},
+		EstimationMonthLower: CostEntry{Cpu: ToDecimal(0.111111)},
+		EstimationMonthUpper: CostEntry{Cpu: ToDecimal(0.555555)},
+		Currency:             "EUR",
+	}}
+	if scaled := child.CostWithEstimation.Scale(2); scaled.Currency != "EUR" {
+		t.Errorf("expected the currency to be kept, got %#v", scaled)
+	}
+	node := CostWithChildren{Children: map[string]CostWithChildren{"a": child, "b": child}}
+	node.Add(child.CostWithEstimation.Scale(2))
+	if !node.EstimationMonthLower.Cpu.Equal(ToDecimal(0.222222)) || !node.EstimationMonthUpper.Cpu.Equal(ToDecimal(1.11111)) {
+		t.Errorf("unexpected bounds %#v", node.CostWithEstimation)
+	}
+
+	rounded := node.Round(Rounding{Mode: RoundingModeHalfUp, Decimals: 2})
+	if !rounded.EstimationMonthLower.Cpu.Equal(ToDecimal(0.22)) || !rounded.EstimationMonthUpper.Cpu.Equal(ToDecimal(1.12)) || !rounded.Children["a"].EstimationMonthUpper.Cpu.Equal(ToDecimal(0.56)) {
+		t.Errorf("unexpected rounded bounds %#v", rounded)
+	}
+}
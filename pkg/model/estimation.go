@@ -16,9 +16,57 @@
 
 package model
 
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
 type Estimation struct {
 	Min    float64 `json:"min"`
 	Mean   float64 `json:"mean"`
 	Median float64 `json:"median"`
 	Max    float64 `json:"max"`
 }
+
+// Scale returns a copy of the estimation with all values multiplied by factor
+func (e Estimation) Scale(factor float64) Estimation {
+	return Estimation{Min: e.Min * factor, Mean: e.Mean * factor, Median: e.Median * factor, Max: e.Max * factor}
+}
+
+// DeviceTypeEstimation is the estimated monthly cost of a number of devices of a device type
+type DeviceTypeEstimation struct {
+	Devices  int        `json:"devices"` // number of existing devices, which the estimation is based on
+	Count    int64      `json:"count"`   // number of devices, which are estimated
+	Storage  Estimation `json:"storage"`
+	Messages Estimation `json:"messages"`
+	Total    Estimation `json:"total"`
+}
+
+type EstimationStrategy = string
+
+const EstimationStrategyLast24h EstimationStrategy = "24h"     // average of the last 24 hours
+const EstimationStrategyLast7d EstimationStrategy = "7d"       // average of the last 7 days
+const EstimationStrategyLinear EstimationStrategy = "linear"   // linear trend of the last 7 days
+const EstimationStrategyWeekday EstimationStrategy = "weekday" // usage of the same weekday one week ago
+
+var EstimationStrategies = []EstimationStrategy{EstimationStrategyLast24h, EstimationStrategyLast7d, EstimationStrategyLinear, EstimationStrategyWeekday}
+
+// EstimationOptions configure how the cost until the end of the month is estimated.
+// The zero value uses the configured defaults.
+type EstimationOptions struct {
+	Strategy EstimationStrategy `json:"strategy,omitempty"` // overrides the configured strategy of all cost types
+	Horizon  *time.Time         `json:"horizon,omitempty"`  // end of the estimation, defaults to the end of the month
+}
+
+func (o EstimationOptions) Validate() error {
+	return ValidateEstimationStrategy(o.Strategy)
+}
+
+// ValidateEstimationStrategy accepts all known strategies and the empty string
+func ValidateEstimationStrategy(strategy EstimationStrategy) error {
+	if strategy != "" && !slices.Contains(EstimationStrategies, strategy) {
+		return fmt.Errorf("unknown estimation strategy %v", strategy)
+	}
+	return nil
+}
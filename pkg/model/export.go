@@ -0,0 +1,76 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// CostRow is a node of a cost tree with the keys of all nodes from the root to the node
+type CostRow struct {
+	Path []string
+	CostWithEstimation
+}
+
+// CostRowColumns are the column names of CostRow.Cells
+var CostRowColumns = []string{
+	"path", "level", "currency",
+	"month_cpu", "month_ram", "month_storage", "month_network", "month_requests", "month_total",
+	"estimation_month_cpu", "estimation_month_ram", "estimation_month_storage", "estimation_month_network", "estimation_month_requests", "estimation_month_total",
+}
+
+// Cells returns the values of the row in the order of CostRowColumns
+func (r CostRow) Cells() []any {
+	cells := []any{strings.Join(r.Path, " / "), len(r.Path), r.Currency}
+	for _, e := range []CostEntry{r.Month, r.EstimationMonth} {
+		for _, amount := range []decimal.Decimal{e.Cpu, e.Ram, e.Storage, e.Network, e.RequestsCost, e.Total()} {
+			cells = append(cells, amount.InexactFloat64())
+		}
+	}
+	return cells
+}
+
+// Flatten returns all nodes of the tree as rows. Parents are followed by their children, siblings are sorted by key.
+func (t CostTree) Flatten() (rows []CostRow) {
+	for _, k := range sortedKeys(t) {
+		rows = append(rows, t[k].Flatten(k)...)
+	}
+	return rows
+}
+
+// Flatten returns the node and all its descendants as rows. path is the path of the node.
+// Children inherit the currency of the node.
+func (c CostWithChildren) Flatten(path ...string) (rows []CostRow) {
+	rows = append(rows, CostRow{Path: path, CostWithEstimation: c.CostWithEstimation})
+	for _, k := range sortedKeys(c.Children) {
+		child := c.Children[k]
+		if child.Currency == "" {
+			child.Currency = c.Currency
+		}
+		rows = append(rows, child.Flatten(append(append([]string{}, path...), k)...)...)
+	}
+	return rows
+}
+
+// pathKey returns a unique key of the path. Names of tree nodes may contain any separator, so the path is encoded.
+func pathKey(path []string) string {
+	b, _ := json.Marshal(path)
+	return string(b)
+}
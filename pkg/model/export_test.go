@@ -0,0 +1,55 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import "testing"
+
+func TestCostTreeFlatten(t *testing.T) {
+	tree := CostTree{
+		CostTypeImports: {
+			CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(1)}, Currency: "EUR"},
+		},
+		CostTypeAnalytics: {
+			CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(3), Ram: ToDecimal(1)}, Currency: "EUR"},
+			Children: map[string]CostWithChildren{
+				"pipeline": {
+					CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(3), Ram: ToDecimal(1)}},
+					Children: map[string]CostWithChildren{
+						"pod": {CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(3)}}},
+					},
+				},
+			},
+		},
+	}
+	rows := tree.Flatten()
+	expected := []string{"analytics", "analytics / pipeline", "analytics / pipeline / pod", "imports"}
+	if len(rows) != len(expected) {
+		t.Fatalf("unexpected rows %#v", rows)
+	}
+	for i, row := range rows {
+		cells := row.Cells()
+		if len(cells) != len(CostRowColumns) {
+			t.Fatalf("expected %v cells, got %v", len(CostRowColumns), len(cells))
+		}
+		if cells[0] != expected[i] || cells[1] != len(row.Path) || cells[2] != "EUR" {
+			t.Errorf("unexpected row %v: %#v", i, cells)
+		}
+	}
+	if !rows[1].Month.Total().Equal(ToDecimal(4)) {
+		t.Errorf("unexpected total %v", rows[1].Month.Total())
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Invoice struct {
+	Number   string        `json:"number"`
+	UserId   string        `json:"user_id"`
+	Month    string        `json:"month"` // formatted as 2006-01
+	IssuedAt time.Time     `json:"issued_at"`
+	Currency string        `json:"currency,omitempty"`
+	Items    []InvoiceItem `json:"items"`
+	Subtotal float64       `json:"subtotal"`
+	Taxes    []InvoiceTax  `json:"taxes,omitempty"`
+	Total    float64       `json:"total"`
+}
+
+type InvoiceItem struct {
+	CostType    CostType `json:"cost_type"`
+	Name        string   `json:"name,omitempty"` // top level child of the cost type, empty for costs without child
+	Description string   `json:"description"`
+	Quantity    float64  `json:"quantity"`
+	Unit        string   `json:"unit"`
+	UnitPrice   float64  `json:"unit_price"`
+	Amount      float64  `json:"amount"`
+}
+
+type InvoiceTax struct {
+	Name   string  `json:"name"`
+	Rate   float64 `json:"rate"`
+	Amount float64 `json:"amount"`
+}
+
+type invoiceDimension struct {
+	description string
+	unit        string
+	quantity    func(e CostEntry) float64
+	amount      func(e CostEntry) decimal.Decimal
+}
+
+var invoiceDimensions = []invoiceDimension{
+	{
+		description: "CPU",
+		unit:        "core hours",
+		quantity:    func(e CostEntry) float64 { return e.CpuHours },
+		amount:      func(e CostEntry) decimal.Decimal { return e.Cpu },
+	},
+	{
+		description: "RAM",
+		unit:        "GB hours",
+		quantity:    func(e CostEntry) float64 { return e.RamGbHours },
+		amount:      func(e CostEntry) decimal.Decimal { return e.Ram },
+	},
+	{
+		description: "Storage",
+		unit:        "GB hours",
+		quantity:    func(e CostEntry) float64 { return e.StorageGbHours },
+		amount:      func(e CostEntry) decimal.Decimal { return e.Storage },
+	},
+	{
+		description: "Network",
+		unit:        "GB",
+		quantity:    func(e CostEntry) float64 { return e.NetworkGb },
+		amount:      func(e CostEntry) decimal.Decimal { return e.Network },
+	},
+	{
+		description: "Requests",
+		unit:        "1000 requests",
+		quantity:    func(e CostEntry) float64 { return e.Requests / 1000 },
+		amount:      func(e CostEntry) decimal.Decimal { return e.RequestsCost },
+	},
+}
+
+// NewInvoice creates an invoice without number from the cost tree of a month. Each cost type and top level child
+// gets a line item per dimension. Amounts are taken from the tree. Since overrides and price changes within the month
+// may apply different prices to a line item, the unit price is the average price amount ÷ quantity.
+func NewInvoice(userId string, month string, tree CostTree, currency string, taxRates []InvoiceTax, rounding Rounding) (invoice Invoice) {
+	invoice = Invoice{
+		UserId:   userId,
+		Month:    month,
+		Currency: currency,
+		Items:    []InvoiceItem{},
+	}
+	subtotal := decimal.Zero
+	addItems := func(costType CostType, name string, entry CostEntry) {
+		for _, dimension := range invoiceDimensions {
+			quantity := dimension.quantity(entry)
+			amount := rounding.round(dimension.amount(entry))
+			if quantity == 0 && amount.IsZero() {
+				continue
+			}
+			unitPrice := 0.0
+			if quantity != 0 {
+				unitPrice = amount.Div(ToDecimal(quantity)).InexactFloat64()
+			}
+			invoice.Items = append(invoice.Items, InvoiceItem{
+				CostType:    costType,
+				Name:        name,
+				Description: dimension.description,
+				Quantity:    quantity,
+				Unit:        dimension.unit,
+				UnitPrice:   unitPrice,
+				Amount:      amount.InexactFloat64(),
+			})
+			subtotal = subtotal.Add(amount)
+		}
+	}
+
+	for _, costType := range sortedKeys(tree) {
+		node := tree[costType]
+		remainder := node.Month
+		for _, name := range sortedKeys(node.Children) {
+			child := node.Children[name]
+			addItems(costType, name, child.Month)
+			remainder.Add(child.Month.Scale(-1))
+		}
+		addItems(costType, "", remainder)
+	}
+
+	invoice.Subtotal = subtotal.InexactFloat64()
+	total := subtotal
+	for _, tax := range taxRates {
+		amount := rounding.round(subtotal.Mul(ToDecimal(tax.Rate)))
+		tax.Amount = amount.InexactFloat64()
+		invoice.Taxes = append(invoice.Taxes, tax)
+		total = total.Add(amount)
+	}
+	invoice.Total = total.InexactFloat64()
+	return invoice
+}
+
+func sortedKeys[T any](m map[string]T) (keys []string) {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
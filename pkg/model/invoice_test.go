@@ -0,0 +1,57 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import "testing"
+
+func TestNewInvoice(t *testing.T) {
+	tree := CostTree{
+		CostTypeAnalytics: {
+			CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(3), CpuHours: 30, Ram: ToDecimal(1), RamGbHours: 20, Network: ToDecimal(0.3), NetworkGb: 2}},
+			Children: map[string]CostWithChildren{
+				"pipeline1": {CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(2), CpuHours: 20, Ram: ToDecimal(1), RamGbHours: 20, Network: ToDecimal(0.3), NetworkGb: 2}}},
+			},
+		},
+		CostTypeApiCalls: {
+			CostWithEstimation: CostWithEstimation{Month: CostEntry{Requests: 2000, RequestsCost: ToDecimal(0.2)}},
+		},
+	}
+	invoice := NewInvoice("user", "2024-01", tree, "EUR", []InvoiceTax{{Name: "VAT", Rate: 0.19}}, Rounding{Decimals: 2, Mode: RoundingModeHalfUp})
+
+	if len(invoice.Items) != 5 {
+		t.Fatalf("unexpected items %#v", invoice.Items)
+	}
+	if item := invoice.Items[0]; item.CostType != CostTypeApiCalls || item.Quantity != 2 || item.UnitPrice != 0.1 || item.Amount != 0.2 {
+		t.Errorf("unexpected api calls item %#v", item)
+	}
+	if item := invoice.Items[1]; item.Name != "pipeline1" || item.Description != "CPU" || item.Quantity != 20 || item.UnitPrice != 0.1 || item.Amount != 2 {
+		t.Errorf("unexpected cpu item %#v", item)
+	}
+	if item := invoice.Items[2]; item.Name != "pipeline1" || item.Description != "RAM" || item.Quantity != 20 || item.UnitPrice != 0.05 || item.Amount != 1 {
+		t.Errorf("unexpected ram item %#v", item)
+	}
+	// transmitted and received traffic have different prices, so the unit price is the average
+	if item := invoice.Items[3]; item.Name != "pipeline1" || item.Description != "Network" || item.Quantity != 2 || item.UnitPrice != 0.15 || item.Amount != 0.3 {
+		t.Errorf("unexpected network item %#v", item)
+	}
+	if item := invoice.Items[4]; item.Name != "" || item.Description != "CPU" || item.Quantity != 10 || item.UnitPrice != 0.1 || item.Amount != 1 {
+		t.Errorf("unexpected remainder item %#v", item)
+	}
+	if invoice.Subtotal != 4.5 || len(invoice.Taxes) != 1 || invoice.Taxes[0].Amount != 0.86 || invoice.Total != 5.36 || invoice.Currency != "EUR" {
+		t.Errorf("unexpected totals %#v", invoice)
+	}
+}
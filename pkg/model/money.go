@@ -0,0 +1,267 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/shopspring/decimal"
+)
+
+type RoundingMode = string
+
+const RoundingModeNone RoundingMode = ""
+const RoundingModeHalfUp RoundingMode = "half_up"
+const RoundingModeHalfEven RoundingMode = "half_even"
+const RoundingModeDown RoundingMode = "down"
+const RoundingModeUp RoundingMode = "up"
+
+type Rounding struct {
+	Decimals int32
+	Mode     RoundingMode
+}
+
+func (r Rounding) Validate() error {
+	switch r.Mode {
+	case RoundingModeNone, RoundingModeHalfUp, RoundingModeHalfEven, RoundingModeDown, RoundingModeUp:
+		return nil
+	default:
+		return fmt.Errorf("unknown rounding mode %v", r.Mode)
+	}
+}
+
+func (r Rounding) round(d decimal.Decimal) decimal.Decimal {
+	switch r.Mode {
+	case RoundingModeHalfUp:
+		return d.Round(r.Decimals)
+	case RoundingModeHalfEven:
+		return d.RoundBank(r.Decimals)
+	case RoundingModeDown:
+		return d.RoundDown(r.Decimals)
+	case RoundingModeUp:
+		return d.RoundUp(r.Decimals)
+	default:
+		return d
+	}
+}
+
+// addDecimal adds quantities a and b based on their shortest decimal representation, so 0.1 + 0.2 == 0.3
+func addDecimal(a float64, b float64) float64 {
+	if math.IsNaN(a) || math.IsInf(a, 0) || math.IsNaN(b) || math.IsInf(b, 0) {
+		return a + b
+	}
+	return decimal.NewFromFloat(a).Add(decimal.NewFromFloat(b)).InexactFloat64()
+}
+
+// ToDecimal converts a calculated float, e.g. price * quantity, into an amount. NaN and infinite values become zero.
+func ToDecimal(f float64) decimal.Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return decimal.Zero
+	}
+	return decimal.NewFromFloat(f)
+}
+
+// money holds the monetary values of a CostEntry
+type money struct {
+	cpu          decimal.Decimal
+	ram          decimal.Decimal
+	storage      decimal.Decimal
+	network      decimal.Decimal
+	requestsCost decimal.Decimal
+}
+
+func moneyOf(e CostEntry) money {
+	return money{
+		cpu:          e.Cpu,
+		ram:          e.Ram,
+		storage:      e.Storage,
+		network:      e.Network,
+		requestsCost: e.RequestsCost,
+	}
+}
+
+func (m money) apply(f func(d decimal.Decimal) decimal.Decimal) money {
+	return money{
+		cpu:          f(m.cpu),
+		ram:          f(m.ram),
+		storage:      f(m.storage),
+		network:      f(m.network),
+		requestsCost: f(m.requestsCost),
+	}
+}
+
+func (m money) add(o money) money {
+	return money{
+		cpu:          m.cpu.Add(o.cpu),
+		ram:          m.ram.Add(o.ram),
+		storage:      m.storage.Add(o.storage),
+		network:      m.network.Add(o.network),
+		requestsCost: m.requestsCost.Add(o.requestsCost),
+	}
+}
+
+func (m money) sub(o money) money {
+	return money{
+		cpu:          m.cpu.Sub(o.cpu),
+		ram:          m.ram.Sub(o.ram),
+		storage:      m.storage.Sub(o.storage),
+		network:      m.network.Sub(o.network),
+		requestsCost: m.requestsCost.Sub(o.requestsCost),
+	}
+}
+
+func (m money) setTo(e *CostEntry) {
+	e.Cpu = m.cpu
+	e.Ram = m.ram
+	e.Storage = m.storage
+	e.Network = m.network
+	e.RequestsCost = m.requestsCost
+}
+
+// Round rounds all monetary values of the entry
+func (e CostEntry) Round(r Rounding) CostEntry {
+	moneyOf(e).apply(r.round).setTo(&e)
+	return e
+}
+
+// Round rounds all monetary values of the node and its children. Rounded values are summed up exactly,
+// so that the total of a node equals the sum of its rounded children plus its own rounded remainder.
+func (c CostWithChildren) Round(r Rounding) CostWithChildren {
+	if r.Mode == RoundingModeNone {
+		return c
+	}
+	entries := c.entries()
+	childrenSum := make([]money, len(entries))
+	roundedChildrenSum := make([]money, len(entries))
+	if c.Children != nil {
+		children := make(map[string]CostWithChildren, len(c.Children))
+		for k, child := range c.Children {
+			rounded := child.Round(r)
+			roundedEntries := rounded.entries()
+			for i, entry := range child.entries() {
+				childrenSum[i] = childrenSum[i].add(moneyOf(*entry))
+				roundedChildrenSum[i] = roundedChildrenSum[i].add(moneyOf(*roundedEntries[i]))
+			}
+			children[k] = rounded
+		}
+		c.Children = children
+	}
+	for i, entry := range entries {
+		moneyOf(*entry).sub(childrenSum[i]).apply(r.round).add(roundedChildrenSum[i]).setTo(entry)
+	}
+
+	if c.Tiers != nil {
+		tiers := make(map[TierDimension]TierUsages, len(c.Tiers))
+		for dimension, usages := range c.Tiers {
+			tiers[dimension] = TierUsages{
+				Month:           roundTierUsages(usages.Month, r),
+				EstimationMonth: roundTierUsages(usages.EstimationMonth, r),
+			}
+		}
+		c.Tiers = tiers
+	}
+	return c
+}
+
+func roundTierUsages(usages []TierUsage, r Rounding) (result []TierUsage) {
+	for _, usage := range usages {
+		usage.Cost = r.round(ToDecimal(usage.Cost)).InexactFloat64()
+		result = append(result, usage)
+	}
+	return
+}
+
+// Convert multiplies all monetary values of the node and its children with rate
+func (c CostWithChildren) Convert(rate decimal.Decimal) CostWithChildren {
+	mul := func(d decimal.Decimal) decimal.Decimal {
+		return d.Mul(rate)
+	}
+	for _, entry := range c.entries() {
+		moneyOf(*entry).apply(mul).setTo(entry)
+	}
+	if c.Children != nil {
+		children := make(map[string]CostWithChildren, len(c.Children))
+		for k, child := range c.Children {
+			children[k] = child.Convert(rate)
+		}
+		c.Children = children
+	}
+	if c.Tiers != nil {
+		tiers := make(map[TierDimension]TierUsages, len(c.Tiers))
+		for dimension, usages := range c.Tiers {
+			tiers[dimension] = TierUsages{
+				Month:           convertTierUsages(usages.Month, rate),
+				EstimationMonth: convertTierUsages(usages.EstimationMonth, rate),
+			}
+		}
+		c.Tiers = tiers
+	}
+	return c
+}
+
+func convertTierUsages(usages []TierUsage, rate decimal.Decimal) (result []TierUsage) {
+	for _, usage := range usages {
+		usage.Price = ToDecimal(usage.Price).Mul(rate).InexactFloat64()
+		usage.Cost = ToDecimal(usage.Cost).Mul(rate).InexactFloat64()
+		result = append(result, usage)
+	}
+	return
+}
+
+// ExchangeRates holds the value of one unit of Base in other currencies
+type ExchangeRates struct {
+	Base  string                     `json:"base"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
+
+func GetExchangeRates(filePath string) (rates ExchangeRates, err error) {
+	f, err := os.ReadFile(filePath)
+	if err != nil {
+		return rates, err
+	}
+	err = json.Unmarshal(f, &rates)
+	return
+}
+
+// Rate returns the factor to convert amounts in currency from to currency to
+func (e ExchangeRates) Rate(from string, to string) (decimal.Decimal, error) {
+	if from == to {
+		return decimal.NewFromInt(1), nil
+	}
+	rate := func(currency string) (decimal.Decimal, error) {
+		if currency == e.Base {
+			return decimal.NewFromInt(1), nil
+		}
+		r, ok := e.Rates[currency]
+		if !ok || r.IsZero() {
+			return r, fmt.Errorf("no exchange rate for %v", currency)
+		}
+		return r, nil
+	}
+	fromRate, err := rate(from)
+	if err != nil {
+		return fromRate, err
+	}
+	toRate, err := rate(to)
+	if err != nil {
+		return toRate, err
+	}
+	return toRate.Div(fromRate), nil
+}
@@ -0,0 +1,106 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCostEntryAdd(t *testing.T) {
+	a := CostEntry{Cpu: ToDecimal(0.1)}
+	a.Add(CostEntry{Cpu: ToDecimal(0.2)})
+	if !a.Cpu.Equal(ToDecimal(0.3)) {
+		t.Errorf("expected exact decimal sum 0.3, got %v", a.Cpu)
+	}
+}
+
+func TestCostWithChildrenRound(t *testing.T) {
+	tree := CostWithChildren{
+		CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(0.015)}},
+		Children: map[string]CostWithChildren{
+			"a": {CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(0.005)}}},
+			"b": {CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(0.005)}}},
+			"c": {CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(0.005)}}},
+		},
+	}
+	rounded := tree.Round(Rounding{Decimals: 2, Mode: RoundingModeHalfUp})
+	if !rounded.Month.Cpu.Equal(ToDecimal(0.03)) {
+		t.Errorf("expected parent to equal the sum of rounded children, got %v", rounded.Month.Cpu)
+	}
+	if !rounded.Children["a"].Month.Cpu.Equal(ToDecimal(0.01)) {
+		t.Errorf("unexpected child value %v", rounded.Children["a"].Month.Cpu)
+	}
+	if !tree.Children["a"].Month.Cpu.Equal(ToDecimal(0.005)) {
+		t.Error("rounding must not modify the original tree")
+	}
+
+	rounded = tree.Round(Rounding{Decimals: 2, Mode: RoundingModeDown})
+	if !rounded.Month.Cpu.Equal(ToDecimal(0)) {
+		t.Errorf("expected parent to equal the sum of rounded children, got %v", rounded.Month.Cpu)
+	}
+}
+
+func TestExchangeRatesRate(t *testing.T) {
+	rates := ExchangeRates{
+		Base: "EUR",
+		Rates: map[string]decimal.Decimal{
+			"USD": decimal.RequireFromString("2"),
+			"CHF": decimal.RequireFromString("4"),
+		},
+	}
+	rate, err := rates.Rate("USD", "CHF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rate.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("unexpected rate %v", rate)
+	}
+	rate, err = rates.Rate("USD", "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rate.Equal(decimal.RequireFromString("0.5")) {
+		t.Errorf("unexpected rate %v", rate)
+	}
+	_, err = rates.Rate("EUR", "GBP")
+	if err == nil {
+		t.Error("expected error for unknown currency")
+	}
+}
+
+func TestCostEntryJson(t *testing.T) {
+	a := CostEntry{Cpu: ToDecimal(0.1), CpuHours: 1}
+	a.Add(CostEntry{Cpu: ToDecimal(0.2), CpuHours: 2})
+	b, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"cpu":0.3,"cpu_hours":3}` {
+		t.Errorf("unexpected json %v", string(b))
+	}
+	result := CostEntry{}
+	err = json.Unmarshal(b, &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Cpu.Equal(a.Cpu) || !result.Ram.IsZero() || result.CpuHours != 3 {
+		t.Errorf("unexpected entry %#v", result)
+	}
+}
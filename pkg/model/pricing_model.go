@@ -17,26 +17,170 @@
 package model
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type PricingModel struct {
-	CPU     float64 `json:"CPU"`
-	RAM     float64 `json:"RAM"`
-	Storage float64 `json:"storage"`
+	ValidFrom time.Time `json:"valid_from"`
+	Currency  string    `json:"currency"`
+	CPU       float64   `json:"CPU"`
+	RAM       float64   `json:"RAM"`
+	Storage   float64   `json:"storage"`
+
+	ApiCalls             float64            `json:"api_calls"`                         // price per 1000 calls
+	ApiCallsByService    map[string]float64 `json:"api_calls_by_service"`              // price per 1000 calls, overrides ApiCalls
+	ApiCallsByConsumer   map[string]float64 `json:"api_calls_by_consumer"`             // price per 1000 calls, overrides ApiCallsByService
+	DeviceMessages       float64            `json:"device_messages"`                   // price per 1000 messages
+	DeviceMessagesByType map[string]float64 `json:"device_messages_by_type,omitempty"` // price per 1000 messages by message type, overrides DeviceMessages
+	NetworkTransmit      float64            `json:"network_transmit"`                  // price per GB transmitted by pods
+	NetworkReceive       float64            `json:"network_receive"`                   // price per GB received by pods
+
+	Tiers map[TierDimension]TieredPrice `json:"tiers,omitempty"`
+
+	StorageClasses map[string]float64       `json:"storage_classes,omitempty"` // storage price by storage class, overrides Storage
+	Namespaces     map[string]PriceOverride `json:"namespaces,omitempty"`      // overrides by namespace, take precedence over CostTypes
+	CostTypes      map[string]PriceOverride `json:"cost_types,omitempty"`      // overrides by cost type
+}
+
+type PriceOverride struct {
+	CPU     *float64 `json:"CPU,omitempty"`
+	RAM     *float64 `json:"RAM,omitempty"`
+	Storage *float64 `json:"storage,omitempty"`
+}
+
+func (m PricingModel) override(namespace string, costType CostType, price func(o PriceOverride) *float64, fallback float64) float64 {
+	if o, ok := m.Namespaces[namespace]; ok && price(o) != nil {
+		return *price(o)
+	}
+	if o, ok := m.CostTypes[costType]; ok && price(o) != nil {
+		return *price(o)
+	}
+	return fallback
+}
+
+// CPUPrice returns the CPU price of a container in the namespace, which belongs to the cost type
+func (m PricingModel) CPUPrice(namespace string, costType CostType) float64 {
+	return m.override(namespace, costType, func(o PriceOverride) *float64 { return o.CPU }, m.CPU)
+}
+
+// RAMPrice returns the RAM price of a container in the namespace, which belongs to the cost type
+func (m PricingModel) RAMPrice(namespace string, costType CostType) float64 {
+	return m.override(namespace, costType, func(o PriceOverride) *float64 { return o.RAM }, m.RAM)
+}
+
+// StoragePrice returns the storage price of a volume of the storage class in the namespace, which belongs to the cost type.
+// Namespace overrides take precedence over cost type overrides, which take precedence over storage class prices.
+// Empty values are used for unknown namespaces or storage classes.
+func (m PricingModel) StoragePrice(namespace string, costType CostType, storageClass string) float64 {
+	fallback := m.Storage
+	if price, ok := m.StorageClasses[storageClass]; ok {
+		fallback = price
+	}
+	return m.override(namespace, costType, func(o PriceOverride) *float64 { return o.Storage }, fallback)
+}
+
+// FlatPrice returns the price per unit of the dimension without considering tiers
+func (m PricingModel) FlatPrice(dimension TierDimension) float64 {
+	switch dimension {
+	case TierDimensionCpu:
+		return m.CPU
+	case TierDimensionRam:
+		return m.RAM
+	case TierDimensionStorage:
+		return m.Storage
+	default:
+		return 0
+	}
+}
+
+// ApiCallPrice returns the price per 1000 calls of the consumer to the service
+func (m PricingModel) ApiCallPrice(service string, consumer string) float64 {
+	if price, ok := m.ApiCallsByConsumer[consumer]; ok {
+		return price
+	}
+	if price, ok := m.ApiCallsByService[service]; ok {
+		return price
+	}
+	return m.ApiCalls
+}
+
+// DeviceMessagePrice returns the price per 1000 device messages of the message type
+func (m PricingModel) DeviceMessagePrice(messageType string) float64 {
+	if price, ok := m.DeviceMessagesByType[messageType]; ok {
+		return price
+	}
+	return m.DeviceMessages
+}
+
+// PricingModelVersions holds all versions of the pricing model, sorted by ValidFrom.
+// The first version is also used for all times before its ValidFrom.
+type PricingModelVersions []PricingModel
+
+// PricingSegment is a part of a time range in which a single pricing model version is valid
+type PricingSegment struct {
+	Start time.Time
+	End   time.Time
+	PricingModel
 }
 
 type pricingModelStr struct {
+	ValidFrom   string `json:"valid_from"`
+	Currency    string `json:"currency"`
 	CPU         string `json:"CPU"`
 	RAM         string `json:"RAM"`
 	Description string `json:"description"`
 	Storage     string `json:"storage"`
+
+	ApiCalls             string            `json:"api_calls"`
+	ApiCallsByService    map[string]string `json:"api_calls_by_service"`
+	ApiCallsByConsumer   map[string]string `json:"api_calls_by_consumer"`
+	DeviceMessages       string            `json:"device_messages"`
+	DeviceMessagesByType map[string]string `json:"device_messages_by_type"`
+	NetworkTransmit      string            `json:"network_transmit"`
+	NetworkReceive       string            `json:"network_receive"`
+
+	Tiers map[string]tieredPriceStr `json:"tiers"`
+
+	StorageClasses map[string]string           `json:"storage_classes"`
+	Namespaces     map[string]priceOverrideStr `json:"namespaces"`
+	CostTypes      map[string]priceOverrideStr `json:"cost_types"`
+}
+
+type priceOverrideStr struct {
+	CPU     string `json:"CPU"`
+	RAM     string `json:"RAM"`
+	Storage string `json:"storage"`
+}
+
+type tieredPriceStr struct {
+	Free  string    `json:"free"`
+	Tiers []tierStr `json:"tiers"`
+}
+
+type tierStr struct {
+	UpTo  string `json:"up_to"`
+	Price string `json:"price"`
 }
 
 func (m *pricingModelStr) toModel() (res PricingModel, err error) {
-	res = PricingModel{}
+	res = PricingModel{Currency: m.Currency}
+	if m.ValidFrom != "" {
+		res.ValidFrom, err = time.Parse(time.RFC3339, m.ValidFrom)
+		if err != nil {
+			return
+		}
+	}
+
 	res.CPU, err = strconv.ParseFloat(m.CPU, 64)
 	if err != nil {
 		return
@@ -52,22 +196,332 @@ func (m *pricingModelStr) toModel() (res PricingModel, err error) {
 		return
 	}
 
+	res.ApiCalls, err = parseOptionalFloat(m.ApiCalls)
+	if err != nil {
+		return
+	}
+
+	res.ApiCallsByService, err = parseFloatMap(m.ApiCallsByService)
+	if err != nil {
+		return
+	}
+
+	res.ApiCallsByConsumer, err = parseFloatMap(m.ApiCallsByConsumer)
+	if err != nil {
+		return
+	}
+
+	res.DeviceMessages, err = parseOptionalFloat(m.DeviceMessages)
+	if err != nil {
+		return
+	}
+
+	res.DeviceMessagesByType, err = parseFloatMap(m.DeviceMessagesByType)
+	if err != nil {
+		return
+	}
+
+	res.NetworkTransmit, err = parseOptionalFloat(m.NetworkTransmit)
+	if err != nil {
+		return
+	}
+
+	res.NetworkReceive, err = parseOptionalFloat(m.NetworkReceive)
+	if err != nil {
+		return
+	}
+
+	if len(m.Tiers) > 0 {
+		res.Tiers = map[TierDimension]TieredPrice{}
+	}
+	for dimension, tieredPrice := range m.Tiers {
+		res.Tiers[dimension], err = tieredPrice.toModel()
+		if err != nil {
+			return
+		}
+	}
+
+	res.StorageClasses, err = parseFloatMap(m.StorageClasses)
+	if err != nil {
+		return
+	}
+
+	res.Namespaces, err = parsePriceOverrideMap(m.Namespaces)
+	if err != nil {
+		return
+	}
+
+	res.CostTypes, err = parsePriceOverrideMap(m.CostTypes)
+	if err != nil {
+		return
+	}
+
 	return
 }
 
-func GetPricingModel(filePath string) (model PricingModel, err error) {
-	f, err := os.ReadFile(filePath)
+func (m *priceOverrideStr) toModel() (res PriceOverride, err error) {
+	parse := func(s string) (*float64, error) {
+		if s == "" {
+			return nil, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		return &f, err
+	}
+	res.CPU, err = parse(m.CPU)
 	if err != nil {
-		return model, err
+		return
+	}
+	res.RAM, err = parse(m.RAM)
+	if err != nil {
+		return
+	}
+	res.Storage, err = parse(m.Storage)
+	return
+}
+
+func parsePriceOverrideMap(m map[string]priceOverrideStr) (res map[string]PriceOverride, err error) {
+	res = map[string]PriceOverride{}
+	for k, v := range m {
+		res[k], err = v.toModel()
+		if err != nil {
+			return
+		}
 	}
-	internalModel := pricingModelStr{}
-	err = json.Unmarshal(f, &internalModel)
+	return
+}
+
+func (m *tieredPriceStr) toModel() (res TieredPrice, err error) {
+	res.Free, err = parseOptionalFloat(m.Free)
+	if err != nil {
+		return
+	}
+	for _, t := range m.Tiers {
+		tier := Tier{}
+		if t.UpTo != "" {
+			upTo, err := strconv.ParseFloat(t.UpTo, 64)
+			if err != nil {
+				return res, err
+			}
+			tier.UpTo = &upTo
+		}
+		tier.Price, err = strconv.ParseFloat(t.Price, 64)
+		if err != nil {
+			return
+		}
+		res.Tiers = append(res.Tiers, tier)
+	}
+	return
+}
+
+func parseOptionalFloat(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func parseFloatMap(m map[string]string) (res map[string]float64, err error) {
+	res = map[string]float64{}
+	for k, v := range m {
+		res[k], err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// GetPricingModels reads the pricing model file. The file may either contain a single pricing model
+// (valid for all times) or a list of pricing models with valid_from timestamps.
+func GetPricingModels(filePath string) (versions PricingModelVersions, err error) {
+	f, err := os.ReadFile(filePath)
 	if err != nil {
-		return model, err
+		return versions, err
+	}
+	internalModels := []pricingModelStr{}
+	if bytes.HasPrefix(bytes.TrimSpace(f), []byte("[")) {
+		err = json.Unmarshal(f, &internalModels)
+	} else {
+		internalModel := pricingModelStr{}
+		err = json.Unmarshal(f, &internalModel)
+		internalModels = append(internalModels, internalModel)
 	}
-	model, err = internalModel.toModel()
 	if err != nil {
-		return model, err
+		return versions, err
+	}
+	versions = PricingModelVersions{}
+	for _, internalModel := range internalModels {
+		model, err := internalModel.toModel()
+		if err != nil {
+			return versions, err
+		}
+		versions = append(versions, model)
 	}
+	slices.SortFunc(versions, func(a, b PricingModel) int {
+		return a.ValidFrom.Compare(b.ValidFrom)
+	})
+	err = versions.Validate()
 	return
 }
+
+var currencyMatch = regexp.MustCompile("^[A-Z]{3}$")
+
+// Validate checks that there is at least one version, that no two versions share a valid_from timestamp,
+// that all versions use the same currency and that all prices are non-negative numbers
+func (v PricingModelVersions) Validate() error {
+	if len(v) == 0 {
+		return errors.New("pricing model has no versions")
+	}
+	for i, version := range v {
+		if i > 0 && !version.ValidFrom.After(v[i-1].ValidFrom) {
+			return fmt.Errorf("multiple pricing model versions valid from %v", version.ValidFrom.Format(time.RFC3339))
+		}
+		if version.Currency != v[0].Currency {
+			return fmt.Errorf("pricing model version valid from %v: currency %v differs from currency %v of the first version", version.ValidFrom.Format(time.RFC3339), version.Currency, v[0].Currency)
+		}
+		err := version.Validate()
+		if err != nil {
+			return fmt.Errorf("pricing model version valid from %v: %w", version.ValidFrom.Format(time.RFC3339), err)
+		}
+	}
+	return nil
+}
+
+func (m PricingModel) Validate() error {
+	if m.Currency != "" && !currencyMatch.MatchString(m.Currency) {
+		return fmt.Errorf("currency %v is not an ISO 4217 code", m.Currency)
+	}
+	prices := map[string]float64{
+		"CPU":              m.CPU,
+		"RAM":              m.RAM,
+		"storage":          m.Storage,
+		"api_calls":        m.ApiCalls,
+		"device_messages":  m.DeviceMessages,
+		"network_transmit": m.NetworkTransmit,
+		"network_receive":  m.NetworkReceive,
+	}
+	for k, price := range m.ApiCallsByService {
+		prices["api_calls_by_service."+k] = price
+	}
+	for k, price := range m.ApiCallsByConsumer {
+		prices["api_calls_by_consumer."+k] = price
+	}
+	for k, price := range m.DeviceMessagesByType {
+		prices["device_messages_by_type."+k] = price
+	}
+	for k, price := range m.StorageClasses {
+		prices["storage_classes."+k] = price
+	}
+	addOverrides := func(prefix string, overrides map[string]PriceOverride) {
+		for k, o := range overrides {
+			if o.CPU != nil {
+				prices[prefix+k+".CPU"] = *o.CPU
+			}
+			if o.RAM != nil {
+				prices[prefix+k+".RAM"] = *o.RAM
+			}
+			if o.Storage != nil {
+				prices[prefix+k+".storage"] = *o.Storage
+			}
+		}
+	}
+	addOverrides("namespaces.", m.Namespaces)
+	addOverrides("cost_types.", m.CostTypes)
+	for dimension, tieredPrice := range m.Tiers {
+		switch dimension {
+		case TierDimensionCpu, TierDimensionRam, TierDimensionStorage:
+		default:
+			return fmt.Errorf("unknown tier dimension %v", dimension)
+		}
+		prices["tiers."+dimension+".free"] = tieredPrice.Free
+		lower := tieredPrice.Free
+		for i, tier := range tieredPrice.Tiers {
+			prices["tiers."+dimension+"."+strconv.Itoa(i)+".price"] = tier.Price
+			if tier.UpTo == nil {
+				if i != len(tieredPrice.Tiers)-1 {
+					return fmt.Errorf("only the last tier of %v may be unlimited", dimension)
+				}
+				continue
+			}
+			if *tier.UpTo <= lower {
+				return fmt.Errorf("tier %v of %v must end above %v", i, dimension, lower)
+			}
+			lower = *tier.UpTo
+		}
+	}
+	for k, price := range prices {
+		if math.IsNaN(price) || math.IsInf(price, 0) || price < 0 {
+			return fmt.Errorf("%v must be a non-negative number", k)
+		}
+	}
+	return nil
+}
+
+// At returns the pricing model valid at t
+func (v PricingModelVersions) At(t time.Time) (model PricingModel) {
+	for i, version := range v {
+		if i == 0 || !version.ValidFrom.After(t) {
+			model = version
+		}
+	}
+	return
+}
+
+// Segments splits the time range between start and end at the boundaries of the pricing model versions
+func (v PricingModelVersions) Segments(start time.Time, end time.Time) (segments []PricingSegment) {
+	if !end.After(start) {
+		return nil
+	}
+	if len(v) == 0 {
+		return []PricingSegment{{Start: start, End: end}}
+	}
+	for i, version := range v {
+		segmentStart := start
+		if i > 0 && version.ValidFrom.After(start) {
+			segmentStart = version.ValidFrom
+		}
+		segmentEnd := end
+		if i+1 < len(v) && v[i+1].ValidFrom.Before(end) {
+			segmentEnd = v[i+1].ValidFrom
+		}
+		if !segmentEnd.After(segmentStart) {
+			continue
+		}
+		segments = append(segments, PricingSegment{Start: segmentStart, End: segmentEnd, PricingModel: version})
+	}
+	return
+}
+
+// PriceHours returns the sum of price * hours of all pricing segments between start and end
+func (v PricingModelVersions) PriceHours(start time.Time, end time.Time, price func(m PricingModel) float64) (result float64) {
+	for _, segment := range v.Segments(start, end) {
+		result += price(segment.PricingModel) * segment.End.Sub(segment.Start).Hours()
+	}
+	return
+}
+
+// PricingChange is an entry of the pricing history
+type PricingChange struct {
+	Id        uint64        `json:"id"`
+	Timestamp time.Time     `json:"timestamp"`
+	UserId    string        `json:"user_id"`
+	Username  string        `json:"username"`
+	Old       *PricingModel `json:"old,omitempty"` // nil if the version was added
+	New       PricingModel  `json:"new"`
+	Deleted   bool          `json:"deleted,omitempty"` // the version valid from New.ValidFrom was removed
+}
+
+// Public returns a copy of the pricing model, which only contains the consumer specific api call prices of the
+// clients of the user. Namespace and cost type overrides are removed. Without username, no consumer prices are kept.
+func (m PricingModel) Public(username string) PricingModel {
+	m.Namespaces = nil
+	m.CostTypes = nil
+	consumers := map[string]float64{}
+	for consumer, price := range m.ApiCallsByConsumer {
+		if username != "" && strings.HasPrefix(consumer, username+"_") {
+			consumers[consumer] = price
+		}
+	}
+	m.ApiCallsByConsumer = consumers
+	return m
+}
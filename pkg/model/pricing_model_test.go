@@ -0,0 +1,176 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetPricingModels(t *testing.T) {
+	dir := t.TempDir()
+
+	legacy := filepath.Join(dir, "legacy.json")
+	err := os.WriteFile(legacy, []byte(`{"CPU": "1", "RAM": "2", "storage": "3", "description": "legacy", "device_messages": "0.5", "device_messages_by_type": {"alarm": "2"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions, err := GetPricingModels(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 || versions[0].CPU != 1 || versions[0].RAM != 2 || versions[0].Storage != 3 {
+		t.Errorf("unexpected legacy pricing model %#v", versions)
+	}
+	if versions[0].DeviceMessagePrice("alarm") != 2 || versions[0].DeviceMessagePrice("measurement") != 0.5 {
+		t.Errorf("unexpected device message prices %#v", versions[0])
+	}
+
+	versioned := filepath.Join(dir, "versioned.json")
+	err = os.WriteFile(versioned, []byte(`[
+		{"valid_from": "2024-02-01T00:00:00Z", "CPU": "2", "RAM": "2", "storage": "2", "network_transmit": "0.1"},
+		{"valid_from": "2024-01-01T00:00:00Z", "CPU": "1", "RAM": "1", "storage": "1"}
+	]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions, err = GetPricingModels(versioned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].CPU != 1 || versions[1].CPU != 2 || versions[1].NetworkTransmit != 0.1 || versions[1].NetworkReceive != 0 {
+		t.Errorf("expected versions sorted by valid_from, got %#v", versions)
+	}
+}
+
+func TestPricingModelVersionsSegments(t *testing.T) {
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	versions := PricingModelVersions{
+		{ValidFrom: jan, CPU: 1},
+		{ValidFrom: feb, CPU: 2},
+		{ValidFrom: mar, CPU: 3},
+	}
+
+	segments := versions.Segments(jan.Add(-24*time.Hour), feb.Add(24*time.Hour))
+	if len(segments) != 2 {
+		t.Fatalf("expected 2 segments, got %#v", segments)
+	}
+	if !segments[0].Start.Equal(jan.Add(-24*time.Hour)) || !segments[0].End.Equal(feb) || segments[0].CPU != 1 {
+		t.Errorf("unexpected first segment %#v", segments[0])
+	}
+	if !segments[1].Start.Equal(feb) || !segments[1].End.Equal(feb.Add(24*time.Hour)) || segments[1].CPU != 2 {
+		t.Errorf("unexpected second segment %#v", segments[1])
+	}
+
+	priceHours := versions.PriceHours(feb.Add(-time.Hour), feb.Add(time.Hour), func(m PricingModel) float64 {
+		return m.CPU
+	})
+	if priceHours != 3 {
+		t.Errorf("expected 1*1h + 2*1h = 3, got %v", priceHours)
+	}
+
+	if versions.At(mar.Add(time.Hour)).CPU != 3 || versions.At(jan.Add(-time.Hour)).CPU != 1 {
+		t.Error("unexpected pricing model version")
+	}
+
+	if len(versions.Segments(feb, feb)) != 0 {
+		t.Error("expected no segments for empty range")
+	}
+}
+
+func TestPricingModelVersionsValidate(t *testing.T) {
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	upTo := 10.0
+	cases := map[string]struct {
+		versions PricingModelVersions
+		valid    bool
+	}{
+		"valid":                   {PricingModelVersions{{ValidFrom: jan, CPU: 1}, {ValidFrom: feb, CPU: 2}}, true},
+		"empty":                   {PricingModelVersions{}, false},
+		"negative price":          {PricingModelVersions{{CPU: -1}}, false},
+		"duplicate valid_from":    {PricingModelVersions{{ValidFrom: jan}, {ValidFrom: jan}}, false},
+		"invalid currency":        {PricingModelVersions{{Currency: "euro"}}, false},
+		"mixed currencies":        {PricingModelVersions{{ValidFrom: jan, Currency: "EUR"}, {ValidFrom: feb, Currency: "USD"}}, false},
+		"unknown tier":            {PricingModelVersions{{Tiers: map[TierDimension]TieredPrice{"gpu": {}}}}, false},
+		"unlimited tier not last": {PricingModelVersions{{Tiers: map[TierDimension]TieredPrice{TierDimensionCpu: {Tiers: []Tier{{Price: 1}, {UpTo: &upTo, Price: 1}}}}}}, false},
+	}
+	for name, tc := range cases {
+		err := tc.versions.Validate()
+		if tc.valid && err != nil {
+			t.Errorf("%v: unexpected error %v", name, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("%v: expected error", name)
+		}
+	}
+}
+
+func TestPricingModelStoragePrice(t *testing.T) {
+	namespacePrice := 4.0
+	costTypePrice := 3.0
+	m := PricingModel{
+		Storage:        1,
+		StorageClasses: map[string]float64{"ssd": 2},
+		Namespaces:     map[string]PriceOverride{"premium": {Storage: &namespacePrice}, "cpu-only": {}},
+		CostTypes:      map[string]PriceOverride{CostTypeAnalytics: {Storage: &costTypePrice}},
+	}
+	cases := []struct {
+		namespace    string
+		costType     CostType
+		storageClass string
+		expected     float64
+	}{
+		{"premium", CostTypeAnalytics, "ssd", namespacePrice},
+		{"other", CostTypeAnalytics, "ssd", costTypePrice},
+		{"cpu-only", CostTypeAnalytics, "ssd", costTypePrice},
+		{"other", CostTypeImports, "ssd", 2},
+		{"other", CostTypeImports, "", 1},
+		{"other", CostTypeImports, "unknown", 1},
+	}
+	for _, tc := range cases {
+		if price := m.StoragePrice(tc.namespace, tc.costType, tc.storageClass); price != tc.expected {
+			t.Errorf("%v/%v/%v: expected %v, got %v", tc.namespace, tc.costType, tc.storageClass, tc.expected, price)
+		}
+	}
+}
+
+func TestPricingModelPublic(t *testing.T) {
+	price := 1.0
+	m := PricingModel{
+		ApiCallsByConsumer: map[string]float64{"user_client": 1, "other_client": 2, "_client": 3},
+		Namespaces:         map[string]PriceOverride{"premium": {CPU: &price}},
+		CostTypes:          map[string]PriceOverride{CostTypeAnalytics: {CPU: &price}},
+	}
+	public := m.Public("user")
+	if len(public.ApiCallsByConsumer) != 1 || public.ApiCallsByConsumer["user_client"] != 1 {
+		t.Errorf("unexpected consumer prices %#v", public.ApiCallsByConsumer)
+	}
+	if public.Namespaces != nil || public.CostTypes != nil {
+		t.Errorf("expected overrides to be removed, got %#v", public)
+	}
+	if len(m.Namespaces) != 1 {
+		t.Error("Public must not modify the original pricing model")
+	}
+	if public := m.Public(""); len(public.ApiCallsByConsumer) != 0 {
+		t.Errorf("expected no consumer prices without username, got %#v", public.ApiCallsByConsumer)
+	}
+}
@@ -0,0 +1,31 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import "time"
+
+// CostSeriesEntry holds the cost of the step, which ends at Time
+type CostSeriesEntry struct {
+	Time time.Time `json:"time"`
+	CostEntry
+}
+
+type CostSeries struct {
+	Values   []CostSeriesEntry            `json:"values"`
+	Children map[string][]CostSeriesEntry `json:"children,omitempty"`
+	Currency string                       `json:"currency,omitempty"`
+}
@@ -0,0 +1,153 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import (
+	"math"
+
+	"github.com/shopspring/decimal"
+)
+
+type TierDimension = string
+
+const TierDimensionCpu TierDimension = "cpu"         // core hours
+const TierDimensionRam TierDimension = "ram"         // GB hours
+const TierDimensionStorage TierDimension = "storage" // GB hours
+
+// TieredPrice replaces the flat price of a dimension with a monthly free allowance and volume tiers.
+// Quantities exceeding the last tier are charged with the flat price.
+type TieredPrice struct {
+	Free  float64 `json:"free"`
+	Tiers []Tier  `json:"tiers"`
+}
+
+type Tier struct {
+	UpTo  *float64 `json:"up_to,omitempty"` // total quantity (including the free allowance) up to which the tier applies, nil for unlimited
+	Price float64  `json:"price"`
+}
+
+type TierUsage struct {
+	From     float64  `json:"from"`
+	To       *float64 `json:"to,omitempty"`
+	Price    float64  `json:"price"`
+	Quantity float64  `json:"quantity"`
+	Cost     float64  `json:"cost"`
+}
+
+type TierUsages struct {
+	Month           []TierUsage `json:"month,omitempty"`
+	EstimationMonth []TierUsage `json:"estimation_month,omitempty"`
+}
+
+// Apply splits the quantity into the free allowance and the tiers and returns the resulting cost
+func (t TieredPrice) Apply(quantity float64, flatPrice float64) (cost float64, usages []TierUsage) {
+	lower := 0.0
+	add := func(upper *float64, price float64) {
+		q := quantity - lower
+		if upper != nil {
+			q = math.Min(quantity, *upper) - lower
+		}
+		if q > 0 {
+			usages = append(usages, TierUsage{From: lower, To: upper, Price: price, Quantity: q, Cost: q * price})
+			cost += q * price
+		}
+		if upper != nil && *upper > lower {
+			lower = *upper
+		}
+	}
+	if t.Free > 0 {
+		free := t.Free
+		add(&free, 0)
+	}
+	for _, tier := range t.Tiers {
+		add(tier.UpTo, tier.Price)
+		if tier.UpTo == nil {
+			return
+		}
+	}
+	add(nil, flatPrice)
+	return
+}
+
+// Quantity returns the quantity of the dimension in the unit its price refers to
+func (a CostEntry) Quantity(dimension TierDimension) float64 {
+	switch dimension {
+	case TierDimensionCpu:
+		return a.CpuHours
+	case TierDimensionRam:
+		return a.RamGbHours
+	case TierDimensionStorage:
+		return a.StorageGbHours
+	default:
+		return 0
+	}
+}
+
+// Cost returns the cost of the dimension
+func (a CostEntry) Cost(dimension TierDimension) decimal.Decimal {
+	switch dimension {
+	case TierDimensionCpu:
+		return a.Cpu
+	case TierDimensionRam:
+		return a.Ram
+	case TierDimensionStorage:
+		return a.Storage
+	default:
+		return decimal.Zero
+	}
+}
+
+// AddCost adds cost to the cost of the dimension
+func (a *CostEntry) AddCost(dimension TierDimension, cost decimal.Decimal) {
+	switch dimension {
+	case TierDimensionCpu:
+		a.Cpu = a.Cpu.Add(cost)
+	case TierDimensionRam:
+		a.Ram = a.Ram.Add(cost)
+	case TierDimensionStorage:
+		a.Storage = a.Storage.Add(cost)
+	}
+}
+
+// TierShare returns the part of the tiers node of the tree, which belongs to the cost type. The adjustment of each
+// dimension is shared by the quantity of the dimension, so that the shares of all cost types add up to the tiers node.
+func (a CostTree) TierShare(costType CostType) (share CostWithEstimation) {
+	tiers, ok := a[CostTypeTiers]
+	if !ok {
+		return share
+	}
+	node := a[costType]
+	shareEntries := share.entries()
+	nodeEntries := node.entries()
+	tierEntries := tiers.entries()
+	for dimension := range tiers.Tiers {
+		for i := range shareEntries {
+			total := 0.0
+			for k, v := range a {
+				if k != CostTypeOverhead && k != CostTypeTiers {
+					total += v.entries()[i].Quantity(dimension)
+				}
+			}
+			if total == 0 {
+				continue
+			}
+			fraction := nodeEntries[i].Quantity(dimension) / total
+			shareEntries[i].AddCost(dimension, tierEntries[i].Cost(dimension).Mul(ToDecimal(fraction)))
+		}
+	}
+	return share
+}
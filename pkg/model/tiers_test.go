@@ -0,0 +1,66 @@
+/*
+ *    Copyright 2023 InfAI (CC SES)
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package model
+
+import "testing"
+
+func TestTieredPriceApply(t *testing.T) {
+	upTo := 110.0
+	tieredPrice := TieredPrice{
+		Free:  10,
+		Tiers: []Tier{{UpTo: &upTo, Price: 2}},
+	}
+
+	cost, usages := tieredPrice.Apply(5, 3)
+	if cost != 0 || len(usages) != 1 || usages[0].Quantity != 5 {
+		t.Errorf("expected quantity within free allowance, got %v %#v", cost, usages)
+	}
+
+	cost, usages = tieredPrice.Apply(150, 3)
+	if cost != 100*2+40*3 {
+		t.Errorf("unexpected cost %v", cost)
+	}
+	if len(usages) != 3 || usages[0].Quantity != 10 || usages[1].Quantity != 100 || usages[2].Quantity != 40 || usages[2].To != nil {
+		t.Errorf("unexpected usages %#v", usages)
+	}
+
+	unlimited := TieredPrice{Tiers: []Tier{{Price: 1}}}
+	cost, usages = unlimited.Apply(150, 3)
+	if cost != 150 || len(usages) != 1 {
+		t.Errorf("expected unlimited tier to replace flat price, got %v %#v", cost, usages)
+	}
+}
+
+func TestCostTreeTierShare(t *testing.T) {
+	tree := CostTree{
+		CostTypeAnalytics: {CostWithEstimation: CostWithEstimation{Month: CostEntry{CpuHours: 30, Cpu: ToDecimal(3)}}},
+		CostTypeImports:   {CostWithEstimation: CostWithEstimation{Month: CostEntry{CpuHours: 10, Cpu: ToDecimal(1)}}},
+		CostTypeOverhead:  {CostWithEstimation: CostWithEstimation{Month: CostEntry{CpuHours: 100, Cpu: ToDecimal(10)}}},
+		CostTypeTiers: {
+			CostWithEstimation: CostWithEstimation{Month: CostEntry{Cpu: ToDecimal(-2)}},
+			Tiers:              map[TierDimension]TierUsages{TierDimensionCpu: {}},
+		},
+	}
+	analytics := tree.TierShare(CostTypeAnalytics)
+	imports := tree.TierShare(CostTypeImports)
+	if !analytics.Month.Cpu.Equal(ToDecimal(-1.5)) || !imports.Month.Cpu.Equal(ToDecimal(-0.5)) {
+		t.Errorf("expected the adjustment to be shared by quantity, got %v and %v", analytics.Month.Cpu, imports.Month.Cpu)
+	}
+	if share := (CostTree{CostTypeAnalytics: tree[CostTypeAnalytics]}).TierShare(CostTypeAnalytics); !share.Month.Total().IsZero() {
+		t.Errorf("expected no share without tiers, got %#v", share)
+	}
+}